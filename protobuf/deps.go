@@ -0,0 +1,80 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+)
+
+// ErrConflictingFile is returned when different proto files with the same
+// name are registered
+var ErrConflictingFile = errors.New("conflicting proto file")
+
+// protoFile is proto file together with its schema string
+type protoFile struct {
+	desc   *desc.FileDescriptor
+	schema string
+}
+
+// sortFiles returns proto file and its dependencies in topological order
+//
+// Each file is listed once and after all files it imports, so the proto file
+// itself is always the last one. Files with the same name, but with different
+// schemas, are reported as conflicting.
+func sortFiles(printer *protoprint.Printer, file *desc.FileDescriptor) ([]*protoFile, error) {
+	files := map[string]*protoFile{}
+	visiting := map[string]bool{}
+	sorted := []*protoFile{}
+
+	var visit func(file *desc.FileDescriptor) error
+	visit = func(file *desc.FileDescriptor) error {
+		name := file.GetName()
+
+		if visiting[name] {
+			return fmt.Errorf("import cycle in proto file %s", name)
+		}
+
+		if visited, ok := files[name]; ok {
+			if visited.desc == file {
+				return nil
+			}
+
+			schema, err := fileDescriptorToSchemaString(printer, file)
+			if err != nil {
+				return err
+			}
+
+			if schema != visited.schema {
+				return fmt.Errorf("%w: %s", ErrConflictingFile, name)
+			}
+
+			return nil
+		}
+
+		visiting[name] = true
+		for _, dep := range file.GetDependencies() {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+
+		schema, err := fileDescriptorToSchemaString(printer, file)
+		if err != nil {
+			return err
+		}
+
+		files[name] = &protoFile{desc: file, schema: schema}
+		sorted = append(sorted, files[name])
+
+		return nil
+	}
+
+	if err := visit(file); err != nil {
+		return nil, err
+	}
+
+	return sorted, nil
+}
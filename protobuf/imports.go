@@ -0,0 +1,71 @@
+package protobuf
+
+// ImportHandling defines how registrator handles imports, that are provided
+// by schema registry
+type ImportHandling int
+
+const (
+	// SkipImports handling neither registers nor references provided
+	// imports, as schema registry has them built in. This is how Confluent
+	// serializers handle well-known types.
+	SkipImports ImportHandling = iota
+
+	// RegisterImports handling registers provided imports like any other
+	// dependency, under subjects of provided imports
+	RegisterImports
+
+	// ReferenceImports handling references latest versions of subjects of
+	// provided imports, which must already be registered
+	ReferenceImports
+)
+
+func (h ImportHandling) String() string {
+	switch h {
+	case SkipImports:
+		return "skip"
+	case RegisterImports:
+		return "register"
+	case ReferenceImports:
+		return "reference"
+	}
+
+	return "unknown"
+}
+
+// wellKnownImports are proto files built into Confluent schema registry
+var wellKnownImports = map[string]bool{
+	"confluent/meta.proto":                 true,
+	"confluent/type/decimal.proto":         true,
+	"google/protobuf/any.proto":            true,
+	"google/protobuf/api.proto":            true,
+	"google/protobuf/descriptor.proto":     true,
+	"google/protobuf/duration.proto":       true,
+	"google/protobuf/empty.proto":          true,
+	"google/protobuf/field_mask.proto":     true,
+	"google/protobuf/source_context.proto": true,
+	"google/protobuf/struct.proto":         true,
+	"google/protobuf/timestamp.proto":      true,
+	"google/protobuf/type.proto":           true,
+	"google/protobuf/wrappers.proto":       true,
+	"google/type/calendar_period.proto":    true,
+	"google/type/color.proto":              true,
+	"google/type/date.proto":               true,
+	"google/type/datetime.proto":           true,
+	"google/type/dayofweek.proto":          true,
+	"google/type/expr.proto":               true,
+	"google/type/fraction.proto":           true,
+	"google/type/interval.proto":           true,
+	"google/type/latlng.proto":             true,
+	"google/type/money.proto":              true,
+	"google/type/month.proto":              true,
+	"google/type/phone_number.proto":       true,
+	"google/type/postal_address.proto":     true,
+	"google/type/quaternion.proto":         true,
+	"google/type/timeofday.proto":          true,
+}
+
+// IsWellKnownImport checks whether imported proto file is one of well-known
+// types built into Confluent schema registry
+func IsWellKnownImport(name string) bool {
+	return wellKnownImports[name]
+}
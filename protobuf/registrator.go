@@ -11,29 +11,138 @@ import (
 	"github.com/xtruder/go-kafka-protobuf/srclient"
 )
 
+type SchemaRegistratorOption func(*SchemaRegistrator)
+
+// WithLookupOnly option makes registrator only look up already registered
+// schemas, without registering them, so it can be used with read-only
+// schema registry credentials
+func WithLookupOnly(enable ...bool) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.lookupOnly = len(enable) == 0 || enable[0]
+	}
+}
+
+// WithSubjectNameStrategy option sets strategy naming subjects of both keys
+// and values, which is TopicNameStrategy by default
+func WithSubjectNameStrategy(strategy SubjectNameStrategy) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.keyStrategy = strategy
+		r.valueStrategy = strategy
+	}
+}
+
+// WithKeySubjectNameStrategy option sets strategy naming subjects of keys
+func WithKeySubjectNameStrategy(strategy SubjectNameStrategy) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.keyStrategy = strategy
+	}
+}
+
+// WithValueSubjectNameStrategy option sets strategy naming subjects of values
+func WithValueSubjectNameStrategy(strategy SubjectNameStrategy) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.valueStrategy = strategy
+	}
+}
+
+// WithReferenceSubjectNameStrategy option sets strategy naming subjects of
+// imported proto files, which is FilePathReferenceSubject by default.
+// Provided imports are named separately by provided import subject.
+func WithReferenceSubjectNameStrategy(strategy ReferenceSubjectNameStrategy) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.refStrategy = strategy
+	}
+}
+
+// WithProvidedImports option sets handling of imports provided by schema
+// registry, which are by default well-known types, or imports checked by
+// isProvided. Provided imports are skipped by default, the same way as
+// Confluent serializers skip well-known types.
+func WithProvidedImports(handling ImportHandling, isProvided ...func(name string) bool) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.importHandling = handling
+		if len(isProvided) > 0 {
+			r.isProvided = isProvided[0]
+		}
+	}
+}
+
+// WithProvidedImportSubject option sets function returning subjects of
+// provided imports, that are registered or referenced, which defaults to
+// import name
+func WithProvidedImportSubject(subject func(name string) string) SchemaRegistratorOption {
+	return func(r *SchemaRegistrator) {
+		r.importSubject = subject
+	}
+}
+
 type SchemaRegistrator struct {
 	srclient srclient.Client
 	printer  *protoprint.Printer
+
+	lookupOnly bool
+
+	keyStrategy   SubjectNameStrategy
+	valueStrategy SubjectNameStrategy
+	refStrategy   ReferenceSubjectNameStrategy
+
+	importHandling ImportHandling
+	isProvided     func(name string) bool
+	importSubject  func(name string) string
+}
+
+// registeredDep is registered dependency with its schema string
+type registeredDep struct {
+	schema string
+	ref    srclient.Reference
 }
 
-func NewSchemaRegistrator(srclient srclient.Client) *SchemaRegistrator {
+func NewSchemaRegistrator(srclient srclient.Client, opts ...SchemaRegistratorOption) *SchemaRegistrator {
 	printer := &protoprint.Printer{ForceFullyQualifiedNames: true}
 
-	return &SchemaRegistrator{
+	r := &SchemaRegistrator{
 		srclient: srclient,
 		printer:  printer,
+
+		keyStrategy:   TopicNameStrategy,
+		valueStrategy: TopicNameStrategy,
+		refStrategy:   FilePathReferenceSubject,
+
+		importHandling: SkipImports,
+		isProvided:     IsWellKnownImport,
+		importSubject:  func(name string) string { return name },
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.keyStrategy == nil || r.valueStrategy == nil || r.refStrategy == nil {
+		panic("subject name strategy must be set")
+	}
+
+	switch r.importHandling {
+	case SkipImports, RegisterImports, ReferenceImports:
+	default:
+		panic(fmt.Errorf("invalid import handling: %d", r.importHandling))
 	}
+
+	return r
 }
 
+// RegisterKey registers schema of message key published to topic, under
+// subject named by key subject name strategy
 func (r *SchemaRegistrator) RegisterKey(ctx context.Context, topic string, msg interface{}) (int, error) {
-	return r.register(ctx, topic+"-key", msg)
+	return r.register(ctx, topic, true, msg)
 }
 
+// RegisterValue registers schema of message value published to topic, under
+// subject named by value subject name strategy
 func (r *SchemaRegistrator) RegisterValue(ctx context.Context, topic string, msg interface{}) (int, error) {
-	return r.register(ctx, topic+"-value", msg)
+	return r.register(ctx, topic, false, msg)
 }
 
-func (r *SchemaRegistrator) register(ctx context.Context, topic string, msg interface{}) (int, error) {
+func (r *SchemaRegistrator) register(ctx context.Context, topic string, isKey bool, msg interface{}) (int, error) {
 	protoMsg, ok := msg.(proto.Message)
 	if !ok {
 		return 0, fmt.Errorf("record type must be of proto.Message")
@@ -44,49 +153,149 @@ func (r *SchemaRegistrator) register(ctx context.Context, topic string, msg inte
 		return 0, fmt.Errorf("error loading message desciprot for message %w", err)
 	}
 
-	fileDesc := msgDesc.GetFile()
-	deps := collectFileDescDeps(fileDesc)
+	strategy := r.valueStrategy
+	if isKey {
+		strategy = r.keyStrategy
+	}
 
-	refs := []srclient.Reference{}
-	for _, dep := range deps {
-		depSchema, err := fileDescriptorToSchemaString(r.printer, dep)
-		if err != nil {
-			return 0, err
+	subject := strategy(topic, isKey, msgDesc.GetFullyQualifiedName())
+
+	return r.registerFile(ctx, subject, msgDesc.GetFile())
+}
+
+// registerFile registers proto file under subject, together with its
+// dependencies, and returns id of registered schema
+//
+// Dependencies are registered once in topological order, each with
+// references to its own direct imports. Provided imports are handled
+// according to import handling. Dependencies are registered again by each
+// registration, as they might have changed since, so registrations should be
+// cached by CachingClient.
+func (r *SchemaRegistrator) registerFile(ctx context.Context, subject string, fileDesc *desc.FileDescriptor) (int, error) {
+	files, err := sortFiles(r.printer, fileDesc)
+	if err != nil {
+		return 0, err
+	}
+
+	root := files[len(files)-1]
+
+	deps := map[string]*registeredDep{}
+	refs := map[string]srclient.Reference{}
+	for _, file := range files[:len(files)-1] {
+		if r.importHandling == SkipImports && r.isProvided(file.desc.GetName()) {
+			continue
 		}
 
-		name := dep.GetName()
-		schema, err := r.srclient.CreateSchema(ctx, &srclient.Schema{
-			Subject: name,
-			Type:    srclient.ProtobufSchemaType,
-			Schema:  depSchema,
-		})
+		ref, err := r.registerDep(ctx, file, deps, refs)
 		if err != nil {
-			return 0, fmt.Errorf("Error creating schema: %w", err)
+			return 0, err
 		}
 
-		refs = append(refs, srclient.Reference{
-			Name:    name,
-			Subject: name,
-			Version: schema.Version,
-		})
+		refs[ref.Name] = ref
 	}
 
-	protoStr, err := fileDescriptorToSchemaString(r.printer, fileDesc)
+	schema, err := r.resolveSchema(ctx, &srclient.Schema{
+		Subject:    subject,
+		Type:       srclient.ProtobufSchemaType,
+		Schema:     root.schema,
+		References: fileReferences(root.desc, refs),
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	schema, err := r.srclient.CreateSchema(ctx, &srclient.Schema{
-		Subject:    topic,
+	return schema.ID, nil
+}
+
+// registerDep registers dependency, unless it was already registered under
+// the same subject by registration, and returns reference to it. References
+// of its imports must be already set.
+func (r *SchemaRegistrator) registerDep(ctx context.Context, file *protoFile, deps map[string]*registeredDep, refs map[string]srclient.Reference) (srclient.Reference, error) {
+	name := file.desc.GetName()
+
+	subject := r.refStrategy(name, file.desc.GetPackage())
+	if r.isProvided(name) {
+		subject = r.importSubject(name)
+	}
+
+	if dep, ok := deps[subject]; ok {
+		if dep.schema != file.schema {
+			return srclient.Reference{}, fmt.Errorf("%w: %s was already registered under subject %s with different schema", ErrConflictingFile, name, subject)
+		}
+
+		return dep.ref, nil
+	}
+
+	ref, err := r.resolveDep(ctx, file, subject, refs)
+	if err != nil {
+		return srclient.Reference{}, err
+	}
+
+	deps[subject] = &registeredDep{schema: file.schema, ref: ref}
+
+	return ref, nil
+}
+
+// resolveDep registers dependency under subject, or references existing
+// subject of provided import with reference import handling. Reference name
+// is always import path, so schema registry can resolve imports.
+func (r *SchemaRegistrator) resolveDep(ctx context.Context, file *protoFile, subject string, refs map[string]srclient.Reference) (srclient.Reference, error) {
+	name := file.desc.GetName()
+
+	if r.isProvided(name) {
+		if r.importHandling == ReferenceImports {
+			schema, err := r.srclient.GetLatestSchema(ctx, subject)
+			if err != nil {
+				return srclient.Reference{}, fmt.Errorf("error getting subject of provided import %s: %w", name, err)
+			}
+
+			return srclient.Reference{Name: name, Subject: subject, Version: schema.Version}, nil
+		}
+	}
+
+	schema, err := r.resolveSchema(ctx, &srclient.Schema{
+		Subject:    subject,
 		Type:       srclient.ProtobufSchemaType,
-		Schema:     protoStr,
-		References: refs,
+		Schema:     file.schema,
+		References: fileReferences(file.desc, refs),
 	})
 	if err != nil {
-		return 0, fmt.Errorf("Error creating schema: %w", err)
+		return srclient.Reference{}, err
 	}
 
-	return schema.ID, nil
+	return srclient.Reference{Name: name, Subject: subject, Version: schema.Version}, nil
+}
+
+// fileReferences returns references to direct imports of proto file, where
+// skipped imports are not referenced
+func fileReferences(file *desc.FileDescriptor, refs map[string]srclient.Reference) []srclient.Reference {
+	result := []srclient.Reference{}
+	for _, dep := range file.GetDependencies() {
+		if ref, ok := refs[dep.GetName()]; ok {
+			result = append(result, ref)
+		}
+	}
+
+	return result
+}
+
+// resolveSchema registers schema or only looks it up in lookup only mode
+func (r *SchemaRegistrator) resolveSchema(ctx context.Context, schema *srclient.Schema) (*srclient.Schema, error) {
+	if r.lookupOnly {
+		result, err := r.srclient.LookupSchema(ctx, schema)
+		if err != nil {
+			return nil, fmt.Errorf("Error looking up schema: %w", err)
+		}
+
+		return result, nil
+	}
+
+	result, err := r.srclient.CreateSchema(ctx, schema)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating schema: %w", err)
+	}
+
+	return result, nil
 }
 
 func (r *SchemaRegistrator) Load(ctx context.Context, schemaID int, name string) ([]*desc.FileDescriptor, error) {
@@ -102,15 +311,13 @@ func (r *SchemaRegistrator) Load(ctx context.Context, schemaID int, name string)
 	fileNames = append(fileNames, name)
 
 	for _, dep := range schema.References {
-		schema, err := r.srclient.GetSchemaByVersion(ctx, dep.Subject, dep.Version)
-		if err != nil {
-			return nil, err
-		}
-
-		schemaFiles[dep.Name] = schema.Schema
 		fileNames = append(fileNames, dep.Name)
 	}
 
+	if err := r.loadReferences(ctx, schema.References, schemaFiles); err != nil {
+		return nil, err
+	}
+
 	accessor := protoparse.FileContentsFromMap(schemaFiles)
 
 	parser := protoparse.Parser{Accessor: accessor}
@@ -122,6 +329,29 @@ func (r *SchemaRegistrator) Load(ctx context.Context, schemaID int, name string)
 	return fileDescriptors, nil
 }
 
+// loadReferences loads schemas of references and their own references into
+// map of schema files
+func (r *SchemaRegistrator) loadReferences(ctx context.Context, refs []srclient.Reference, schemaFiles map[string]string) error {
+	for _, ref := range refs {
+		if _, ok := schemaFiles[ref.Name]; ok {
+			continue
+		}
+
+		schema, err := r.srclient.GetSchemaByVersion(ctx, ref.Subject, ref.Version)
+		if err != nil {
+			return err
+		}
+
+		schemaFiles[ref.Name] = schema.Schema
+
+		if err := r.loadReferences(ctx, schema.References, schemaFiles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func fileDescriptorToSchemaString(printer *protoprint.Printer, file *desc.FileDescriptor) (string, error) {
 	result, err := printer.PrintProtoToString(file)
 	if err != nil {
@@ -130,18 +360,3 @@ func fileDescriptorToSchemaString(printer *protoprint.Printer, file *desc.FileDe
 
 	return result, nil
 }
-
-// reursively collect file descriptor dependencies
-func collectFileDescDeps(file *desc.FileDescriptor) []*desc.FileDescriptor {
-	var collectDeps func(file *desc.FileDescriptor) []*desc.FileDescriptor
-	collectDeps = func(file *desc.FileDescriptor) (deps []*desc.FileDescriptor) {
-		for _, dep := range file.GetDependencies() {
-			deps = append(deps, dep)
-			deps = append(deps, collectDeps(dep)...)
-		}
-
-		return
-	}
-
-	return collectDeps(file)
-}
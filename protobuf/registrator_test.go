@@ -2,15 +2,23 @@ package protobuf
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/desc/protoprint"
 	"github.com/stretchr/testify/require"
 	"github.com/xtruder/go-kafka-protobuf/protobuf/fixture"
 	"github.com/xtruder/go-kafka-protobuf/srclient"
+	"github.com/xtruder/go-kafka-protobuf/srclient/srtest"
 )
 
 func TestProtobufSchemaRegistrator(t *testing.T) {
-	client := srclient.NewClient(srclient.WithURL("http://schema-registry:8081"))
+	server := srtest.NewServer()
+	defer server.Close()
+
+	client := srclient.NewClient(srclient.WithURL(server.URL))
 	registrator := NewSchemaRegistrator(client)
 	id, err := registrator.RegisterValue(context.Background(), "user-value", &fixture.User{})
 	require.NoError(t, err)
@@ -18,3 +26,370 @@ func TestProtobufSchemaRegistrator(t *testing.T) {
 	_, err = registrator.Load(context.Background(), id, "schema.proto")
 	require.NoError(t, err)
 }
+
+func TestProtobufSchemaRegistratorLookupOnly(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+	lookupRegistrator := NewSchemaRegistrator(client, WithLookupOnly())
+
+	_, err := lookupRegistrator.RegisterValue(context.Background(), "user", &fixture.User{})
+	require.True(t, errors.Is(err, srclient.ErrNotFound))
+
+	subjects, err := client.GetSubjects(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, subjects)
+
+	id, err := NewSchemaRegistrator(client).RegisterValue(context.Background(), "user", &fixture.User{})
+	require.NoError(t, err)
+
+	lookupID, err := lookupRegistrator.RegisterValue(context.Background(), "user", &fixture.User{})
+	require.NoError(t, err)
+	require.Equal(t, id, lookupID)
+}
+
+func TestProtobufSchemaRegistratorTransitiveDeps(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	files := parseProtoFiles(t, map[string]string{
+		"a.proto": `syntax = "proto3"; package test; import "b.proto"; message A { B b = 1; }`,
+		"b.proto": `syntax = "proto3"; package test; import "c.proto"; message B { C c = 1; }`,
+		"c.proto": `syntax = "proto3"; package test; message C { string name = 1; }`,
+	}, "a.proto")
+
+	registrator := NewSchemaRegistrator(client)
+	id, err := registrator.registerFile(ctx, "topic-value", files[0])
+	require.NoError(t, err)
+
+	// root schema references only its direct imports
+	schema, err := client.GetSchemaByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []srclient.Reference{{Name: "b.proto", Subject: "b.proto", Version: 1}}, schema.References)
+
+	// dependencies reference their own direct imports
+	schema, err = client.GetLatestSchema(ctx, "b.proto")
+	require.NoError(t, err)
+	require.Equal(t, []srclient.Reference{{Name: "c.proto", Subject: "c.proto", Version: 1}}, schema.References)
+
+	schema, err = client.GetLatestSchema(ctx, "c.proto")
+	require.NoError(t, err)
+	require.Empty(t, schema.References)
+
+	loaded, err := registrator.Load(ctx, id, "a.proto")
+	require.NoError(t, err)
+	require.NotNil(t, loaded[0].FindMessage("test.A"))
+	require.NotNil(t, loaded[0].FindMessage("test.A").FindFieldByName("b").GetMessageType())
+}
+
+// countingClient counts schemas created by subject
+type countingClient struct {
+	srclient.Client
+	created map[string]int
+}
+
+func (c *countingClient) CreateSchema(ctx context.Context, schema *srclient.Schema) (*srclient.Schema, error) {
+	c.created[schema.Subject]++
+	return c.Client.CreateSchema(ctx, schema)
+}
+
+func parseProtoFiles(t *testing.T, files map[string]string, names ...string) []*desc.FileDescriptor {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(files)}
+
+	result, err := parser.ParseFiles(names...)
+	require.NoError(t, err)
+
+	return result
+}
+
+func TestProtobufSchemaRegistratorDiamondDeps(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := &countingClient{Client: srclient.NewClient(srclient.WithURL(server.URL)), created: map[string]int{}}
+
+	files := parseProtoFiles(t, map[string]string{
+		"a.proto":      `syntax = "proto3"; package test; import "b.proto"; import "c.proto"; message A { B b = 1; C c = 2; }`,
+		"b.proto":      `syntax = "proto3"; package test; import "common.proto"; message B { Common common = 1; }`,
+		"c.proto":      `syntax = "proto3"; package test; import "common.proto"; message C { Common common = 1; }`,
+		"d.proto":      `syntax = "proto3"; package test; import "c.proto"; message D { C c = 1; }`,
+		"common.proto": `syntax = "proto3"; package test; message Common { string name = 1; }`,
+	}, "a.proto", "d.proto")
+
+	sorted, err := sortFiles(&protoprint.Printer{}, files[0])
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, file := range sorted {
+		names = append(names, file.desc.GetName())
+	}
+	require.Equal(t, []string{"common.proto", "b.proto", "c.proto", "a.proto"}, names)
+
+	registrator := NewSchemaRegistrator(srclient.NewCachingClient(client))
+	id, err := registrator.registerFile(ctx, "a-value", files[0])
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"common.proto": 1, "b.proto": 1, "c.proto": 1, "a-value": 1}, client.created)
+
+	schema, err := client.GetSchemaByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []srclient.Reference{
+		{Name: "b.proto", Subject: "b.proto", Version: 1},
+		{Name: "c.proto", Subject: "c.proto", Version: 1},
+	}, schema.References)
+
+	// already registered dependencies are cached by caching client
+	_, err = registrator.registerFile(ctx, "d-value", files[1])
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"common.proto": 1, "b.proto": 1, "c.proto": 1, "a-value": 1, "d-value": 1}, client.created)
+}
+
+func TestProtobufSchemaRegistratorConflictingFiles(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	x := parseProtoFiles(t, map[string]string{
+		"x.proto":      `syntax = "proto3"; package x; import "common.proto"; message X { Common common = 1; }`,
+		"common.proto": `syntax = "proto3"; message Common { string name = 1; }`,
+	}, "x.proto")[0]
+
+	y := parseProtoFiles(t, map[string]string{
+		"y.proto":      `syntax = "proto3"; package y; import "common.proto"; message Y { Common common = 1; }`,
+		"common.proto": `syntax = "proto3"; message Common { string value = 1; }`,
+	}, "y.proto")[0]
+
+	root := parseProtoFiles(t, map[string]string{
+		"root.proto": `syntax = "proto3"; import "x.proto"; import "y.proto";`,
+		"x.proto":    `syntax = "proto3";`,
+		"y.proto":    `syntax = "proto3";`,
+	}, "root.proto")[0]
+
+	root, err := desc.CreateFileDescriptor(root.AsFileDescriptorProto(), x, y)
+	require.NoError(t, err)
+
+	// files with same name and different schemas are reported
+	registrator := NewSchemaRegistrator(client)
+	_, err = registrator.registerFile(ctx, "root-value", root)
+	require.True(t, errors.Is(err, ErrConflictingFile))
+
+	// dependencies changed between registrations are registered as new
+	// versions
+	_, err = registrator.registerFile(ctx, "x-value", x)
+	require.NoError(t, err)
+
+	id, err := registrator.registerFile(ctx, "y-value", y)
+	require.NoError(t, err)
+
+	schema, err := client.GetSchemaByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []srclient.Reference{{Name: "common.proto", Subject: "common.proto", Version: 2}}, schema.References)
+}
+
+func TestProtobufSchemaRegistratorProvidedImports(t *testing.T) {
+	ctx := context.Background()
+
+	register := func(t *testing.T, client srclient.Client, opts ...SchemaRegistratorOption) ([]srclient.Reference, error) {
+		id, err := NewSchemaRegistrator(client, opts...).RegisterValue(ctx, "user", &fixture.User{})
+		if err != nil {
+			return nil, err
+		}
+
+		schema, err := client.GetSchemaByID(ctx, id)
+		require.NoError(t, err)
+
+		return schema.References, nil
+	}
+
+	itemRef := srclient.Reference{Name: "item.proto", Subject: "item.proto", Version: 1}
+
+	t.Run("skip", func(t *testing.T) {
+		server := srtest.NewServer()
+		defer server.Close()
+
+		client := srclient.NewClient(srclient.WithURL(server.URL))
+
+		refs, err := register(t, client)
+		require.NoError(t, err)
+		require.Equal(t, []srclient.Reference{itemRef}, refs)
+
+		subjects, err := client.GetSubjects(ctx)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"item.proto", "user-value"}, subjects)
+	})
+
+	t.Run("register", func(t *testing.T) {
+		server := srtest.NewServer()
+		defer server.Close()
+
+		client := srclient.NewClient(srclient.WithURL(server.URL))
+
+		refs, err := register(t, client,
+			WithProvidedImports(RegisterImports),
+			WithProvidedImportSubject(func(name string) string { return "wkt/" + name }))
+		require.NoError(t, err)
+		require.Equal(t, []srclient.Reference{
+			{Name: "google/protobuf/timestamp.proto", Subject: "wkt/google/protobuf/timestamp.proto", Version: 1},
+			itemRef,
+		}, refs)
+	})
+
+	t.Run("reference", func(t *testing.T) {
+		server := srtest.NewServer()
+		defer server.Close()
+
+		client := srclient.NewClient(srclient.WithURL(server.URL))
+
+		// provided imports must be registered
+		_, err := register(t, client, WithProvidedImports(ReferenceImports))
+		require.True(t, errors.Is(err, srclient.ErrSubjectNotFound))
+
+		for _, schema := range []string{"a", "b"} {
+			_, err = client.CreateSchema(ctx, &srclient.Schema{Subject: "google/protobuf/timestamp.proto", Schema: schema})
+			require.NoError(t, err)
+		}
+
+		refs, err := register(t, client, WithProvidedImports(ReferenceImports))
+		require.NoError(t, err)
+		require.Equal(t, []srclient.Reference{
+			{Name: "google/protobuf/timestamp.proto", Subject: "google/protobuf/timestamp.proto", Version: 2},
+			itemRef,
+		}, refs)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		server := srtest.NewServer()
+		defer server.Close()
+
+		client := srclient.NewClient(srclient.WithURL(server.URL))
+
+		// item.proto is provided, while well-known types are not
+		refs, err := register(t, client, WithProvidedImports(SkipImports, func(name string) bool { return name == "item.proto" }))
+		require.NoError(t, err)
+		require.Equal(t, []srclient.Reference{
+			{Name: "google/protobuf/timestamp.proto", Subject: "google/protobuf/timestamp.proto", Version: 1},
+		}, refs)
+	})
+
+	require.Panics(t, func() { NewSchemaRegistrator(nil, WithProvidedImports(ImportHandling(10))) })
+}
+
+func TestProtobufSchemaRegistratorSubjectNameStrategy(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	subjects := func(t *testing.T) []string {
+		subjects, err := client.GetSubjects(ctx)
+		require.NoError(t, err)
+
+		result := []string{}
+		for _, subject := range subjects {
+			if subject != "item.proto" {
+				result = append(result, subject)
+			}
+		}
+
+		return result
+	}
+
+	registrator := NewSchemaRegistrator(client, WithSubjectNameStrategy(TopicRecordNameStrategy))
+	_, err := registrator.RegisterKey(ctx, "events", &fixture.Item{})
+	require.NoError(t, err)
+	_, err = registrator.RegisterValue(ctx, "events", &fixture.User{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"events-fixture.Item", "events-fixture.User"}, subjects(t))
+
+	// key and value strategies are set separately
+	server.Reset()
+	registrator = NewSchemaRegistrator(client,
+		WithValueSubjectNameStrategy(RecordNameStrategy),
+		WithKeySubjectNameStrategy(func(topic string, isKey bool, recordName string) string {
+			return "custom-" + topic
+		}))
+	_, err = registrator.RegisterKey(ctx, "events", &fixture.Item{})
+	require.NoError(t, err)
+	_, err = registrator.RegisterValue(ctx, "events", &fixture.User{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"custom-events", "fixture.User"}, subjects(t))
+
+	// topic name strategy is used by default
+	server.Reset()
+	registrator = NewSchemaRegistrator(client)
+	_, err = registrator.RegisterKey(ctx, "events", &fixture.Item{})
+	require.NoError(t, err)
+	_, err = registrator.RegisterValue(ctx, "events", &fixture.User{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"events-key", "events-value"}, subjects(t))
+
+	require.Panics(t, func() { NewSchemaRegistrator(client, WithSubjectNameStrategy(nil)) })
+}
+
+func TestProtobufSchemaRegistratorReferenceSubjectNameStrategy(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	a := parseProtoFiles(t, map[string]string{
+		"a.proto":      `syntax = "proto3"; package a; import "common.proto"; message A { team_a.Common common = 1; }`,
+		"common.proto": `syntax = "proto3"; package team_a; message Common { string name = 1; }`,
+	}, "a.proto")[0]
+
+	b := parseProtoFiles(t, map[string]string{
+		"b.proto":      `syntax = "proto3"; package b; import "common.proto"; message B { team_b.Common common = 1; }`,
+		"common.proto": `syntax = "proto3"; package team_b; message Common { string value = 1; }`,
+	}, "b.proto")[0]
+
+	// files with the same path in different packages do not collide
+	registrator := NewSchemaRegistrator(client, WithReferenceSubjectNameStrategy(PackageReferenceSubject))
+	for subject, file := range map[string]*desc.FileDescriptor{"a-value": a, "b-value": b} {
+		id, err := registrator.registerFile(ctx, subject, file)
+		require.NoError(t, err)
+
+		schema, err := client.GetSchemaByID(ctx, id)
+		require.NoError(t, err)
+
+		// reference name is import path
+		pkg := "team_" + subject[:1]
+		require.Equal(t, []srclient.Reference{{Name: "common.proto", Subject: pkg + "/common.proto", Version: 1}}, schema.References)
+	}
+
+	// prefixed subjects
+	registrator = NewSchemaRegistrator(client, WithReferenceSubjectNameStrategy(PrefixReferenceSubject("team-a/")))
+	id, err := registrator.registerFile(ctx, "a-value", a)
+	require.NoError(t, err)
+
+	schema, err := client.GetSchemaByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []srclient.Reference{{Name: "common.proto", Subject: "team-a/common.proto", Version: 1}}, schema.References)
+
+	loaded, err := registrator.Load(ctx, id, "a.proto")
+	require.NoError(t, err)
+	require.NotNil(t, loaded[0].FindMessage("a.A"))
+
+	// subjects of provided imports are not named by reference strategy
+	registrator = NewSchemaRegistrator(client,
+		WithReferenceSubjectNameStrategy(PrefixReferenceSubject("team-a/")),
+		WithProvidedImports(RegisterImports))
+	id, err = registrator.RegisterValue(ctx, "user", &fixture.User{})
+	require.NoError(t, err)
+
+	schema, err = client.GetSchemaByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []srclient.Reference{
+		{Name: "google/protobuf/timestamp.proto", Subject: "google/protobuf/timestamp.proto", Version: 1},
+		{Name: "item.proto", Subject: "team-a/item.proto", Version: 1},
+	}, schema.References)
+
+	require.Panics(t, func() { NewSchemaRegistrator(client, WithReferenceSubjectNameStrategy(nil)) })
+}
@@ -0,0 +1,56 @@
+package protobuf
+
+// SubjectNameStrategy returns subject name, under which schema of record is
+// registered, where record name is fully qualified name of message and
+// isKey is set for message keys
+type SubjectNameStrategy func(topic string, isKey bool, recordName string) string
+
+// TopicNameStrategy names subjects by topic, with -key or -value suffix
+func TopicNameStrategy(topic string, isKey bool, recordName string) string {
+	if isKey {
+		return topic + "-key"
+	}
+
+	return topic + "-value"
+}
+
+// RecordNameStrategy names subjects by fully qualified record name, so
+// records of different types can be published to the same topic
+func RecordNameStrategy(topic string, isKey bool, recordName string) string {
+	return recordName
+}
+
+// TopicRecordNameStrategy names subjects by topic and fully qualified record
+// name, as topic-record
+func TopicRecordNameStrategy(topic string, isKey bool, recordName string) string {
+	return topic + "-" + recordName
+}
+
+// ReferenceSubjectNameStrategy returns subject name, under which imported
+// proto file is registered, where name is import path of file and pkg is its
+// proto package
+type ReferenceSubjectNameStrategy func(name string, pkg string) string
+
+// FilePathReferenceSubject names subjects of imported files by import path
+func FilePathReferenceSubject(name string, pkg string) string {
+	return name
+}
+
+// PackageReferenceSubject names subjects of imported files by import path
+// qualified with proto package, as package/path, so files with the same
+// path in different packages do not collide
+func PackageReferenceSubject(name string, pkg string) string {
+	if pkg == "" {
+		return name
+	}
+
+	return pkg + "/" + name
+}
+
+// PrefixReferenceSubject returns strategy naming subjects of imported files
+// by import path with prefix, like prefix per team
+func PrefixReferenceSubject(prefix string) ReferenceSubjectNameStrategy {
+	return func(name string, pkg string) string {
+		return prefix + name
+	}
+}
@@ -0,0 +1,20 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectNameStrategies(t *testing.T) {
+	require.Equal(t, "topic-key", TopicNameStrategy("topic", true, "fixture.User"))
+	require.Equal(t, "topic-value", TopicNameStrategy("topic", false, "fixture.User"))
+	require.Equal(t, "fixture.User", RecordNameStrategy("topic", true, "fixture.User"))
+	require.Equal(t, "fixture.User", RecordNameStrategy("topic", false, "fixture.User"))
+	require.Equal(t, "topic-fixture.User", TopicRecordNameStrategy("topic", false, "fixture.User"))
+
+	require.Equal(t, "common.proto", FilePathReferenceSubject("common.proto", "team"))
+	require.Equal(t, "team.billing/common.proto", PackageReferenceSubject("common.proto", "team.billing"))
+	require.Equal(t, "common.proto", PackageReferenceSubject("common.proto", ""))
+	require.Equal(t, "billing/common.proto", PrefixReferenceSubject("billing/")("common.proto", "team"))
+}
@@ -0,0 +1,221 @@
+package srclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HeaderTargetSRCluster is confluent cloud header for selecting logical
+	// schema registry cluster, when using OAuth authentication
+	HeaderTargetSRCluster = "target-sr-cluster"
+
+	// HeaderIdentityPoolID is confluent cloud header for selecting identity
+	// pool, when using OAuth authentication
+	HeaderIdentityPoolID = "Confluent-Identity-Pool-Id"
+)
+
+// AuthProvider authenticates requests made to schema registry
+type AuthProvider interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// AuthError is returned when request authentication fails
+type AuthError struct {
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("error authenticating request: %v", e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+type basicAuthProvider struct {
+	username string
+	password string
+}
+
+// BasicAuth creates auth provider using static basic auth credentials
+func BasicAuth(username string, password string) AuthProvider {
+	return &basicAuthProvider{username, password}
+}
+
+func (p *basicAuthProvider) Authenticate(ctx context.Context, req *http.Request) error {
+	req.SetBasicAuth(p.username, p.password)
+	return nil
+}
+
+type bearerTokenProvider struct {
+	token string
+}
+
+// BearerToken creates auth provider using static bearer token
+func BearerToken(token string) AuthProvider {
+	return &bearerTokenProvider{token}
+}
+
+func (p *bearerTokenProvider) Authenticate(ctx context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	return nil
+}
+
+// OAuth2Config defines configuration for OAuth2 client credentials flow
+type OAuth2Config struct {
+	// TokenURL is URL of token endpoint
+	TokenURL string
+
+	// ClientID is OAuth2 client ID
+	ClientID string
+
+	// ClientSecret is OAuth2 client secret
+	ClientSecret string
+
+	// Scopes are optional requested scopes
+	Scopes []string
+
+	// EndpointParams are additional parameters sent to token endpoint,
+	// like audience
+	EndpointParams url.Values
+
+	// ExpiryDelta defines how long before expiry token is refreshed,
+	// defaults to 30 seconds
+	ExpiryDelta time.Duration
+
+	// HTTPClient is http client used for token requests
+	HTTPClient *http.Client
+}
+
+// OAuth2Provider is auth provider using OAuth2 client credentials flow,
+// which automatically refreshes access token before it expires
+type OAuth2Provider struct {
+	config OAuth2Config
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+
+	now func() time.Time
+}
+
+// OAuth2ClientCredentials creates auth provider using OAuth2 client
+// credentials flow
+func OAuth2ClientCredentials(config OAuth2Config) *OAuth2Provider {
+	if config.TokenURL == "" {
+		panic(fmt.Errorf("oauth2 token url must be set"))
+	}
+
+	if config.ExpiryDelta == 0 {
+		config.ExpiryDelta = 30 * time.Second
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{}
+	}
+
+	return &OAuth2Provider{config: config, now: time.Now}
+}
+
+func (p *OAuth2Provider) Authenticate(ctx context.Context, req *http.Request) error {
+	token, err := p.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return nil
+}
+
+// Token returns current access token, requesting new one if token is
+// missing or about to expire
+func (p *OAuth2Provider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && (p.refreshAt.IsZero() || p.now().Before(p.refreshAt)) {
+		return p.token, nil
+	}
+
+	token, expiresIn, err := p.requestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = token
+	p.refreshAt = time.Time{}
+
+	if expiresIn > 0 {
+		// refresh token before it expires, but not sooner than in half
+		// of its lifetime
+		delta := p.config.ExpiryDelta
+		if delta > expiresIn/2 {
+			delta = expiresIn / 2
+		}
+
+		p.refreshAt = p.now().Add(expiresIn - delta)
+	}
+
+	return p.token, nil
+}
+
+func (p *OAuth2Provider) requestToken(ctx context.Context) (string, time.Duration, error) {
+	params := url.Values{}
+	for key, values := range p.config.EndpointParams {
+		params[key] = values
+	}
+
+	params.Set("grant_type", "client_credentials")
+	if len(p.config.Scopes) > 0 {
+		params.Set("scope", strings.Join(p.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("error requesting oauth2 token: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("error reading oauth2 token response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", 0, fmt.Errorf("error requesting oauth2 token: %s: %s", resp.Status, body)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", 0, fmt.Errorf("error unmarshalling oauth2 token response: %w", err)
+	}
+
+	if tokenResp.AccessToken == "" {
+		return "", 0, fmt.Errorf("oauth2 token response is missing access token")
+	}
+
+	return tokenResp.AccessToken, time.Duration(tokenResp.ExpiresIn) * time.Second, nil
+}
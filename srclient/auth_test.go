@@ -0,0 +1,168 @@
+package srclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer is local stand-in for oauth2 token endpoint
+type tokenServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	requests  int
+	expiresIn int
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	s := &tokenServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "unsupported_grant_type"}`))
+			return
+		}
+
+		s.requests++
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d-%s", s.requests, r.PostForm.Get("scope")),
+			"token_type":   "Bearer",
+			"expires_in":   s.expiresIn,
+		})
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestBasicAuth(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+
+	err := BasicAuth("user", "pass").Authenticate(context.Background(), req)
+	require.NoError(t, err)
+
+	username, password, ok := req.BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", username)
+	require.Equal(t, "pass", password)
+}
+
+func TestBearerToken(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+
+	err := BearerToken("token").Authenticate(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	server := newTokenServer(t, 3600)
+
+	now := time.Now()
+	provider := OAuth2ClientCredentials(OAuth2Config{
+		TokenURL:     server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"schema-registry"},
+		ExpiryDelta:  time.Minute,
+	})
+	provider.now = func() time.Time { return now }
+
+	token, err := provider.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1-schema-registry", token)
+
+	// token is reused until it is about to expire
+	now = now.Add(58 * time.Minute)
+	token, err = provider.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1-schema-registry", token)
+
+	// token is refreshed before expiry
+	now = now.Add(time.Minute)
+	req := httptest.NewRequest("GET", "/", nil)
+	err = provider.Authenticate(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token-2-schema-registry", req.Header.Get("Authorization"))
+}
+
+func TestOAuth2ClientCredentialsInvalid(t *testing.T) {
+	server := newTokenServer(t, 3600)
+
+	provider := OAuth2ClientCredentials(OAuth2Config{
+		TokenURL:     server.URL,
+		ClientID:     "client",
+		ClientSecret: "invalid",
+	})
+
+	_, err := provider.Token(context.Background())
+	require.Error(t, err)
+}
+
+func TestBaseClientAuth(t *testing.T) {
+	tokenServer := newTokenServer(t, 3600)
+
+	requests := 0
+	headers := http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		headers = r.Header.Clone()
+		w.Write([]byte(`["subject"]`))
+	}))
+	defer server.Close()
+
+	c := NewBaseClient(
+		WithURL(server.URL),
+		WithAuth(OAuth2ClientCredentials(OAuth2Config{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+		})),
+		WithHeader(HeaderTargetSRCluster, "lsrc-123"),
+		WithHeader(HeaderIdentityPoolID, "pool-123"),
+	)
+
+	_, err := c.GetSubjects(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token-1-", headers.Get("Authorization"))
+	require.Equal(t, "lsrc-123", headers.Get(HeaderTargetSRCluster))
+	require.Equal(t, "pool-123", headers.Get(HeaderIdentityPoolID))
+
+	// authentication errors are not retried
+	c = NewBaseClient(
+		WithURL(server.URL),
+		WithAuth(OAuth2ClientCredentials(OAuth2Config{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "client",
+			ClientSecret: "invalid",
+		})),
+	)
+
+	_, err = c.GetSubjects(context.Background())
+
+	var authErr *AuthError
+	require.True(t, errors.As(err, &authErr))
+	require.Equal(t, 1, requests)
+}
@@ -3,9 +3,7 @@ package srclient
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +12,8 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -21,16 +21,22 @@ const (
 	urlSchemaByIDVersions     = urlPath("/schemas/ids/%s/versions")
 	urlSubjects               = urlPath("/subjects")
 	urlSubjectSchemaByVersion = urlPath("/subjects/%s/versions/%s")
+	urlSubjectReferencedBy    = urlPath("/subjects/%s/versions/%s/referencedby")
 	urlSubject                = urlPath("/subjects/%s")
 	urlSubjectVersions        = urlPath("/subjects/%s/versions")
 	urlSchemaCompatibility    = urlPath("/compatibility/subjects/%s/versions/%s")
+	urlSchemaCompatibilityAll = urlPath("/compatibility/subjects/%s/versions")
+	urlConfig                 = urlPath("/config")
+	urlSubjectConfig          = urlPath("/config/%s")
+	urlMode                   = urlPath("/mode")
+	urlSubjectMode            = urlPath("/mode/%s")
 )
 
-var ErrNotFound = errors.New("404 not found")
-
 const contentType = "application/vnd.schemaregistry.v1+json"
 
 type schemaRequest struct {
+	ID         int         `json:"id,omitempty"`
+	Version    int         `json:"version,omitempty"`
 	Schema     string      `json:"schema"`
 	SchemaType string      `json:"schemaType"`
 	References []Reference `json:"references"`
@@ -43,59 +49,138 @@ func schemaRequestFromSchema(schema *Schema) (request schemaRequest) {
 	return
 }
 
-type credentials struct {
-	username string
-	password string
+type configRequest struct {
+	Compatibility CompatibilityLevel `json:"compatibility"`
+}
+
+// configResponse is returned by config endpoints, which return compatibility
+// level either as compatibilityLevel or compatibility field
+type configResponse struct {
+	CompatibilityLevel CompatibilityLevel `json:"compatibilityLevel"`
+	Compatibility      CompatibilityLevel `json:"compatibility"`
+}
+
+func (r *configResponse) level() CompatibilityLevel {
+	if r.CompatibilityLevel != "" {
+		return r.CompatibilityLevel
+	}
+
+	return r.Compatibility
+}
+
+type modeRequest struct {
+	Mode Mode `json:"mode"`
+}
+
+type modeResponse struct {
+	Mode Mode `json:"mode"`
 }
 
 type BaseClientOption func(*BaseClient)
 
 func (BaseClientOption) OptionType() {}
 
-// WithURL option sets URL for client
+func parseURL(val string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(val))
+	if err != nil {
+		panic(fmt.Errorf("schema registry url is invalid '%s': %w", val, err))
+	}
+
+	if u.Hostname() == "" {
+		panic(fmt.Errorf("schema registry url is missing hostname: '%s'", val))
+	}
+
+	return u
+}
+
+// WithURL option sets URLs for client
+//
+// URL can be passed as string, comma separated list of URLs like
+// 'http://sr1:8081,http://sr2:8081', list of strings, *url.URL or list
+// of *url.URL. If multiple URLs are set, client rotates to next URL on
+// connection errors and server errors.
 func WithURL(val interface{}) BaseClientOption {
-	var u *url.URL
-	var err error
+	urls := []*url.URL{}
 
 	switch v := val.(type) {
 	case string:
-		if u, err = url.Parse(v); err != nil {
-			panic(fmt.Errorf("schema registry url is invalid '%s': %w", v, err))
+		for _, u := range strings.Split(v, ",") {
+			urls = append(urls, parseURL(u))
 		}
-
-		if u.Hostname() == "" {
-			panic(fmt.Errorf("schema registry url is missing hostname: '%s'", v))
+	case []string:
+		for _, u := range v {
+			urls = append(urls, parseURL(u))
 		}
 	case *url.URL:
-		u = v
+		urls = append(urls, v)
+	case []*url.URL:
+		urls = append(urls, v...)
 	default:
-		panic(fmt.Errorf("schema registry url is of invalid type: %s", reflect.TypeOf(u).String()))
+		panic(fmt.Errorf("schema registry url is of invalid type: %s", reflect.TypeOf(val).String()))
+	}
+
+	if len(urls) == 0 {
+		panic(fmt.Errorf("no schema registry url provided"))
 	}
 
 	return func(c *BaseClient) {
-		c.url = u
+		c.urls = urls
+		c.urlIndex = 0
 	}
 }
 
-// WithInsecure overrides default transport and sets insecure skip verify
-func WithInsecure(insecure ...bool) BaseClientOption {
+// WithRetries option sets maximum number of request retries on connection
+// errors and server errors, zero disables retries
+func WithRetries(maxRetries int) BaseClientOption {
 	return func(c *BaseClient) {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: enableOpt(insecure)},
-		}
+		c.retry.maxRetries = maxRetries
+	}
+}
 
-		c.httpClient.Transport = tr
+// WithBackoff option sets initial and maximum retry backoff, backoff is
+// doubled on every retry. Retry-After durations requested by schema registry
+// are also limited to maximum backoff.
+func WithBackoff(initial time.Duration, max time.Duration) BaseClientOption {
+	return func(c *BaseClient) {
+		c.retry.initialBackoff = initial
+		c.retry.maxBackoff = max
 	}
 }
 
-// WithCredentials option sets credentials for client
+// WithBackoffJitter option sets random backoff jitter as fraction of backoff,
+// so with jitter of 0.2 backoff is randomized between 80% and 120%
+func WithBackoffJitter(jitter float64) BaseClientOption {
+	return func(c *BaseClient) {
+		c.retry.jitter = jitter
+	}
+}
+
+// WithCredentials option sets basic auth credentials for client
 func WithCredentials(username string, password string) BaseClientOption {
+	return WithAuth(BasicAuth(username, password))
+}
+
+// WithAuth option sets auth provider used to authenticate requests
+func WithAuth(auth AuthProvider) BaseClientOption {
 	return func(c *BaseClient) {
-		c.credentials = &credentials{username, password}
+		c.auth = auth
+	}
+}
+
+// WithHeader option sets extra header sent with every request, like
+// HeaderTargetSRCluster or HeaderIdentityPoolID for confluent cloud
+func WithHeader(name string, value string) BaseClientOption {
+	return func(c *BaseClient) {
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+
+		c.headers.Set(name, value)
 	}
 }
 
-// WithHTTPClient option sets http client to use for requests
+// WithHTTPClient option sets http client to use for requests, where TLS
+// options are only applied to transports of type *http.Transport
 func WithHTTPClient(httpClient *http.Client) BaseClientOption {
 	if httpClient == nil {
 		panic(fmt.Errorf("no http client provided"))
@@ -108,18 +193,38 @@ func WithHTTPClient(httpClient *http.Client) BaseClientOption {
 
 var defaultBaseClientOpts = []BaseClientOption{
 	WithURL("http://localhost:8081"),
+	WithRetries(3),
+	WithBackoff(100*time.Millisecond, 2*time.Second),
+	WithBackoffJitter(0.2),
 }
 
 // BaseClient defines schema registry http client
 type BaseClient struct {
 	httpClient *http.Client
 
-	url         *url.URL
-	credentials *credentials
+	urls     []*url.URL
+	urlIndex int32
+	auth     AuthProvider
+	headers  http.Header
+	retry    retryPolicy
+	tls      *tlsOptions
 }
 
-// NewBaseClient creates new HTTP client
+// NewBaseClient creates new HTTP client, panicking if TLS options cannot be
+// applied, like when certificate files cannot be read, so clients configured
+// at runtime should be created with NewBaseClientWithError
 func NewBaseClient(opts ...BaseClientOption) *BaseClient {
+	c, err := NewBaseClientWithError(opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// NewBaseClientWithError creates new HTTP client like NewBaseClient, but
+// returns error if TLS options cannot be applied
+func NewBaseClientWithError(opts ...BaseClientOption) (*BaseClient, error) {
 	c := &BaseClient{
 		httpClient: &http.Client{},
 	}
@@ -132,7 +237,16 @@ func NewBaseClient(opts ...BaseClientOption) *BaseClient {
 		opt(c)
 	}
 
-	return c
+	if c.tls != nil {
+		httpClient, err := configureTLS(c.httpClient, c.tls)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring tls: %w", err)
+		}
+
+		c.httpClient = httpClient
+	}
+
+	return c, nil
 }
 
 // GetSubjects method gets list of defined subjects
@@ -156,6 +270,18 @@ func (c *BaseClient) GetSubjectVersions(ctx context.Context, subject string) ([]
 	return versions, nil
 }
 
+// GetAllSubjectVersions gets versions of subject including soft deleted
+// versions
+func (c *BaseClient) GetAllSubjectVersions(ctx context.Context, subject string) ([]int, error) {
+	versions := []int{}
+	err := c.jsonRequest(ctx, "GET", urlSubjectVersions.Format(subject)+"?deleted=true", nil, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("error getting all schema versions: %w", err)
+	}
+
+	return versions, nil
+}
+
 func (c *BaseClient) GetSchemaByID(ctx context.Context, schemaID int) (*Schema, error) {
 	schema := &Schema{}
 	err := c.jsonRequest(ctx, "GET", urlSchemaByID.Format(schemaID), nil, schema)
@@ -178,11 +304,28 @@ func (c *BaseClient) GetSchemaByVersion(ctx context.Context, subject string, ver
 }
 
 func (c *BaseClient) CreateSchema(ctx context.Context, schema *Schema) (*Schema, error) {
+	return c.createSchema(ctx, schema, false)
+}
+
+// ImportSchema registers schema under subject with its schema ID and
+// version, which requires schema registry or subject to be in import mode
+func (c *BaseClient) ImportSchema(ctx context.Context, schema *Schema) (*Schema, error) {
+	return c.createSchema(ctx, schema, true)
+}
+
+func (c *BaseClient) createSchema(ctx context.Context, schema *Schema, isImport bool) (*Schema, error) {
 	type createSchemaResponse struct {
 		ID int `json:"id"`
 	}
 
 	schemaReq := schemaRequestFromSchema(schema)
+
+	// schema id and version can be only set in import mode
+	if isImport {
+		schemaReq.ID = schema.ID
+		schemaReq.Version = schema.Version
+	}
+
 	createSchemaResp := &createSchemaResponse{}
 	if err := c.jsonRequest(ctx, "POST", urlSubjectVersions.Format(schema.Subject), schemaReq, createSchemaResp); err != nil {
 		return nil, fmt.Errorf("error creating schema: %w", err)
@@ -193,6 +336,11 @@ func (c *BaseClient) CreateSchema(ctx context.Context, schema *Schema) (*Schema,
 	// set schema ID
 	result.ID = createSchemaResp.ID
 
+	// imported schema is registered under requested version
+	if isImport && result.Version > 0 {
+		return &result, nil
+	}
+
 	// get updated schema version
 	versions, err := c.getSchemaSubjectVersions(ctx, createSchemaResp.ID)
 	if err != nil {
@@ -205,6 +353,25 @@ func (c *BaseClient) CreateSchema(ctx context.Context, schema *Schema) (*Schema,
 	return &result, nil
 }
 
+// LookupSchema looks up whether schema is registered under subject and returns
+// registered schema with schema ID and version set, or ErrNotFound if schema
+// is not registered
+func (c *BaseClient) LookupSchema(ctx context.Context, schema *Schema) (*Schema, error) {
+	schemaReq := schemaRequestFromSchema(schema)
+
+	resp := &Schema{}
+	if err := c.jsonRequest(ctx, "POST", urlSubject.Format(schema.Subject), schemaReq, resp); err != nil {
+		return nil, fmt.Errorf("error looking up schema: %w", err)
+	}
+
+	result := *schema
+
+	result.ID = resp.ID
+	result.Version = resp.Version
+
+	return &result, nil
+}
+
 func (c *BaseClient) DeleteSubject(ctx context.Context, subject string, permanent bool) ([]int, error) {
 	url := urlSubject.Format(subject)
 
@@ -249,7 +416,7 @@ func (c *BaseClient) IsSchemaCompatible(ctx context.Context, schema *Schema) (bo
 	}
 
 	schemaReq := schemaRequestFromSchema(schema)
-	uri := urlSchemaCompatibility.Format(schema.Subject, schema.Version)
+	uri := urlSchemaCompatibility.Format(schema.Subject, compatibilityVersion(schema))
 
 	resp := &response{}
 
@@ -261,10 +428,210 @@ func (c *BaseClient) IsSchemaCompatible(ctx context.Context, schema *Schema) (bo
 	return resp.IsCompatible, nil
 }
 
+// CheckSchemaCompatibility checks compatibility of schema and returns
+// incompatibility messages
+//
+// Schema is checked against schema version or latest version if version is
+// not set, or against all versions checked by subject compatibility level
+// if allVersions is set.
+func (c *BaseClient) CheckSchemaCompatibility(ctx context.Context, schema *Schema, allVersions bool) (*CompatibilityResult, error) {
+	type response struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}
+
+	schemaReq := schemaRequestFromSchema(schema)
+
+	uri := urlSchemaCompatibility.Format(schema.Subject, compatibilityVersion(schema))
+	if allVersions {
+		uri = urlSchemaCompatibilityAll.Format(schema.Subject)
+	}
+
+	uri += "?verbose=true"
+
+	resp := &response{}
+
+	err := c.jsonRequest(ctx, "POST", uri, schemaReq, resp)
+	if err != nil {
+		return nil, fmt.Errorf("error checking schema compatibility: %w", err)
+	}
+
+	return &CompatibilityResult{
+		IsCompatible: resp.IsCompatible,
+		Messages:     parseCompatibilityMessages(resp.Messages),
+	}, nil
+}
+
+// compatibilityVersion returns version schema is checked against
+func compatibilityVersion(schema *Schema) interface{} {
+	if schema.Version == 0 {
+		return "latest"
+	}
+
+	return schema.Version
+}
+
+// GetGlobalCompatibilityLevel gets global compatibility level
+func (c *BaseClient) GetGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error) {
+	resp := &configResponse{}
+	if err := c.jsonRequest(ctx, "GET", urlConfig, nil, resp); err != nil {
+		return "", fmt.Errorf("error getting global compatibility level: %w", err)
+	}
+
+	return resp.level(), nil
+}
+
+// SetGlobalCompatibilityLevel sets global compatibility level
+func (c *BaseClient) SetGlobalCompatibilityLevel(ctx context.Context, level CompatibilityLevel) error {
+	if err := c.jsonRequest(ctx, "PUT", urlConfig, &configRequest{level}, nil); err != nil {
+		return fmt.Errorf("error setting global compatibility level: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGlobalCompatibilityLevel resets global compatibility level to registry
+// default and returns previous compatibility level
+func (c *BaseClient) DeleteGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error) {
+	resp := &configResponse{}
+	if err := c.jsonRequest(ctx, "DELETE", urlConfig, nil, resp); err != nil {
+		return "", fmt.Errorf("error deleting global compatibility level: %w", err)
+	}
+
+	return resp.level(), nil
+}
+
+// GetCompatibilityLevel gets subject compatibility level. If defaultToGlobal
+// is set and subject has no compatibility level configured, global
+// compatibility level is returned.
+func (c *BaseClient) GetCompatibilityLevel(ctx context.Context, subject string, defaultToGlobal bool) (CompatibilityLevel, error) {
+	url := urlSubjectConfig.Format(subject)
+	if defaultToGlobal {
+		url += "?defaultToGlobal=true"
+	}
+
+	resp := &configResponse{}
+	if err := c.jsonRequest(ctx, "GET", url, nil, resp); err != nil {
+		return "", fmt.Errorf("error getting subject compatibility level: %w", err)
+	}
+
+	return resp.level(), nil
+}
+
+// SetCompatibilityLevel sets subject compatibility level
+func (c *BaseClient) SetCompatibilityLevel(ctx context.Context, subject string, level CompatibilityLevel) error {
+	if err := c.jsonRequest(ctx, "PUT", urlSubjectConfig.Format(subject), &configRequest{level}, nil); err != nil {
+		return fmt.Errorf("error setting subject compatibility level: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCompatibilityLevel deletes subject compatibility level, so global
+// compatibility level is used, and returns previous compatibility level
+func (c *BaseClient) DeleteCompatibilityLevel(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	resp := &configResponse{}
+	if err := c.jsonRequest(ctx, "DELETE", urlSubjectConfig.Format(subject), nil, resp); err != nil {
+		return "", fmt.Errorf("error deleting subject compatibility level: %w", err)
+	}
+
+	return resp.level(), nil
+}
+
+// GetGlobalMode gets global schema registry mode
+func (c *BaseClient) GetGlobalMode(ctx context.Context) (Mode, error) {
+	resp := &modeResponse{}
+	if err := c.jsonRequest(ctx, "GET", urlMode, nil, resp); err != nil {
+		return "", fmt.Errorf("error getting global mode: %w", err)
+	}
+
+	return resp.Mode, nil
+}
+
+// SetGlobalMode sets global schema registry mode. Force is required
+// for switching non-empty schema registry into import mode.
+func (c *BaseClient) SetGlobalMode(ctx context.Context, mode Mode, force bool) error {
+	url := urlMode
+	if force {
+		url += "?force=true"
+	}
+
+	if err := c.jsonRequest(ctx, "PUT", url, &modeRequest{mode}, nil); err != nil {
+		return fmt.Errorf("error setting global mode: %w", err)
+	}
+
+	return nil
+}
+
+// GetMode gets subject mode. If defaultToGlobal is set and subject has no
+// mode configured, global mode is returned.
+func (c *BaseClient) GetMode(ctx context.Context, subject string, defaultToGlobal bool) (Mode, error) {
+	url := urlSubjectMode.Format(subject)
+	if defaultToGlobal {
+		url += "?defaultToGlobal=true"
+	}
+
+	resp := &modeResponse{}
+	if err := c.jsonRequest(ctx, "GET", url, nil, resp); err != nil {
+		return "", fmt.Errorf("error getting subject mode: %w", err)
+	}
+
+	return resp.Mode, nil
+}
+
+// SetMode sets subject mode. Force is required for switching non-empty
+// subject into import mode.
+func (c *BaseClient) SetMode(ctx context.Context, subject string, mode Mode, force bool) error {
+	url := urlSubjectMode.Format(subject)
+	if force {
+		url += "?force=true"
+	}
+
+	if err := c.jsonRequest(ctx, "PUT", url, &modeRequest{mode}, nil); err != nil {
+		return fmt.Errorf("error setting subject mode: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMode deletes subject mode, so global mode is used, and returns
+// previous subject mode
+func (c *BaseClient) DeleteMode(ctx context.Context, subject string) (Mode, error) {
+	resp := &modeResponse{}
+	if err := c.jsonRequest(ctx, "DELETE", urlSubjectMode.Format(subject), nil, resp); err != nil {
+		return "", fmt.Errorf("error deleting subject mode: %w", err)
+	}
+
+	return resp.Mode, nil
+}
+
 func (c *BaseClient) GetSchemaSubjectVersions(ctx context.Context, schemaID int) (map[string]int, error) {
 	return c.getSchemaSubjectVersions(ctx, schemaID)
 }
 
+// GetSchemaVersions gets all subject versions registered with schema, while
+// GetSchemaSubjectVersions returns single version per subject
+func (c *BaseClient) GetSchemaVersions(ctx context.Context, schemaID int) ([]SubjectVersion, error) {
+	versions := []SubjectVersion{}
+	err := c.jsonRequest(ctx, "GET", urlSchemaByIDVersions.Format(schemaID), nil, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("error getting schema by id version: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetReferencedBy gets ids of schemas referencing subject version
+func (c *BaseClient) GetReferencedBy(ctx context.Context, subject string, version int) ([]int, error) {
+	ids := []int{}
+	err := c.jsonRequest(ctx, "GET", urlSubjectReferencedBy.Format(subject, version), nil, &ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting schemas referencing subject version: %w", err)
+	}
+
+	return ids, nil
+}
+
 func (c *BaseClient) getSchemaByVersion(ctx context.Context, subject string, version string) (*Schema, error) {
 	resp := &Schema{}
 
@@ -289,66 +656,104 @@ func (c *BaseClient) getSchemaByVersion(ctx context.Context, subject string, ver
 }
 
 func (c *BaseClient) getSchemaSubjectVersions(ctx context.Context, schemaID int) (map[string]int, error) {
-	result := map[string]int{}
-
-	type subjectVersions struct {
-		Subject string `json:"subject"`
-		Version int    `json:"version"`
-	}
-
-	resp := []subjectVersions{}
-	err := c.jsonRequest(ctx, "GET", urlSchemaByIDVersions.Format(schemaID), nil, &resp)
+	versions, err := c.GetSchemaVersions(ctx, schemaID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting schema by id version: %w", err)
+		return nil, err
 	}
 
-	for _, elem := range resp {
+	result := map[string]int{}
+	for _, elem := range versions {
 		result[elem.Subject] = elem.Version
 	}
 
 	return result, nil
 }
 
-func (c *BaseClient) httpRequest(ctx context.Context, method string, path urlPath, payload io.Reader) ([]byte, error) {
+// httpRequest makes http request to schema registry
+//
+// Requests failing with connection errors, server errors or with too many
+// requests status are retried on next schema registry URL with exponential
+// backoff. Registering schemas and updating configuration are idempotent
+// operations in schema registry, so they are safe to retry, while deletes
+// fail if they are repeated, so they are only retried if they were not
+// processed, that is on failed connection or too many requests status.
+func (c *BaseClient) httpRequest(ctx context.Context, method string, path urlPath, payload []byte) ([]byte, error) {
+	retryable := isRetryable
+	if method == "DELETE" {
+		retryable = isRetryableDelete
+	}
+
+	for attempt := 0; ; attempt++ {
+		urlIndex := atomic.LoadInt32(&c.urlIndex)
+
+		resp, retryAfter, err := c.doHTTPRequest(ctx, c.urls[int(urlIndex)%len(c.urls)], method, path, payload)
+		if err == nil {
+			return resp, nil
+		}
+
+		if !retryable(ctx, err) || attempt >= c.retry.maxRetries {
+			return nil, err
+		}
+
+		// rotate to next url, unless some other request already rotated it
+		atomic.CompareAndSwapInt32(&c.urlIndex, urlIndex, (urlIndex+1)%int32(len(c.urls)))
+
+		if err := sleepContext(ctx, c.retry.backoff(attempt, retryAfter)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *BaseClient) doHTTPRequest(ctx context.Context, baseURL *url.URL, method string, path urlPath, payload []byte) ([]byte, time.Duration, error) {
 	// construct full url
-	url := strings.TrimRight(c.url.String(), "/") + "/" + strings.TrimLeft(string(path), "/")
+	url := strings.TrimRight(baseURL.String(), "/") + "/" + strings.TrimLeft(string(path), "/")
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, string(method), url, payload)
+	req, err := http.NewRequestWithContext(ctx, string(method), url, body)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	for name, values := range c.headers {
+		req.Header[name] = values
 	}
 
-	if c.credentials != nil {
-		req.SetBasicAuth(c.credentials.username, c.credentials.password)
+	if c.auth != nil {
+		if err := c.auth.Authenticate(ctx, req); err != nil {
+			return nil, 0, &AuthError{err}
+		}
 	}
 
 	req.Header.Set("Content-Type", contentType)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	if resp != nil {
-		defer resp.Body.Close()
-	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, createHTTPError(resp)
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), createHTTPError(resp)
 	}
 
-	return ioutil.ReadAll(resp.Body)
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, 0, err
 }
 
 func (c *BaseClient) jsonRequest(ctx context.Context, method string, path urlPath, req interface{}, resp interface{}) error {
-	var payload io.Reader
+	var payload []byte
 	if req != nil {
 		data, err := json.Marshal(req)
 		if err != nil {
 			return fmt.Errorf("error marshaling json http payload: %w", err)
 		}
 
-		payload = bytes.NewBuffer(data)
+		payload = data
 	}
 
 	r, err := c.httpRequest(ctx, method, path, payload)
@@ -374,13 +779,12 @@ func createHTTPError(resp *http.Response) error {
 	}
 
 	if err := decoder.Decode(&errorResp); err != nil {
-		return fmt.Errorf("%s", resp.Status)
-
+		return &RegistryError{StatusCode: resp.StatusCode}
 	}
 
-	if resp.StatusCode == 404 {
-		return fmt.Errorf("%w: %s", ErrNotFound, errorResp.Message)
+	return &RegistryError{
+		StatusCode: resp.StatusCode,
+		ErrorCode:  errorResp.ErrorCode,
+		Message:    errorResp.Message,
 	}
-
-	return fmt.Errorf("%s: %s", resp.Status, errorResp.Message)
 }
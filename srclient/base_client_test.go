@@ -1,14 +1,21 @@
 package srclient
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/xtruder/go-kafka-protobuf/srclient/srtest"
 )
 
 func init() {
@@ -17,11 +24,16 @@ func init() {
 
 var _ Client = (*BaseClient)(nil)
 
-func newTestBaseClient() *BaseClient {
+// newTestBaseClient creates base client for schema registry set by
+// SCHEMA_REGISTRY_URL or for fake in-memory schema registry if not set
+func newTestBaseClient(t *testing.T) *BaseClient {
 	url := os.Getenv("SCHEMA_REGISTRY_URL")
 
 	if url == "" {
-		panic("SCHEMA_REGISTRY_URL url not set")
+		server := srtest.NewServer()
+		t.Cleanup(server.Close)
+
+		url = server.URL
 	}
 
 	return NewBaseClient(WithURL(url))
@@ -43,10 +55,179 @@ func TestBaseClient(t *testing.T) {
 	require.IsType(t, &BaseClient{}, c)
 }
 
+func TestBaseClientURLs(t *testing.T) {
+	c := NewBaseClient(WithURL("http://sr1:8081, http://sr2:8081"))
+	require.Len(t, c.urls, 2)
+	require.Equal(t, "sr1:8081", c.urls[0].Host)
+	require.Equal(t, "sr2:8081", c.urls[1].Host)
+
+	c = NewBaseClient(WithURL([]string{"http://sr1:8081", "http://sr2:8081", "http://sr3:8081"}))
+	require.Len(t, c.urls, 3)
+
+	require.Panics(t, func() { WithURL("http://sr1:8081,invalid") })
+	require.Panics(t, func() { WithURL(8081) })
+}
+
+func TestBaseClientFailover(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	// first registry is not reachable
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c := NewBaseClient(
+		WithURL([]string{down.URL, server.URL}),
+		WithBackoff(time.Millisecond, time.Millisecond),
+	)
+
+	schema, err := c.CreateSchema(context.Background(), makeSchema(withRandomSubject))
+	require.NoError(t, err)
+	require.Equal(t, 1, schema.ID)
+
+	// client sticks with url that works
+	require.Equal(t, int32(1), c.urlIndex)
+
+	_, err = c.GetSchemaByID(context.Background(), schema.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), c.urlIndex)
+}
+
+func TestBaseClientRetry(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	requests := 0
+	bodies := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		// registry is unavailable for first two requests
+		if requests <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	c := NewBaseClient(
+		WithURL(proxy.URL),
+		WithRetries(2),
+		WithBackoff(time.Millisecond, 10*time.Millisecond),
+	)
+
+	schema := makeSchema(withRandomSubject)
+
+	_, err := c.CreateSchema(context.Background(), schema)
+	require.NoError(t, err)
+
+	// request body is resent on every retry
+	require.Len(t, bodies, 4)
+	require.Equal(t, bodies[0], bodies[2])
+
+	// requests are retried up to maximum number of retries
+	requests = 0
+	c = NewBaseClient(WithURL(proxy.URL), WithRetries(1), WithBackoff(time.Millisecond, time.Millisecond))
+	_, err = c.GetSubjects(context.Background())
+	require.True(t, errors.Is(err, ErrServerError))
+	require.Equal(t, 2, requests)
+}
+
+func TestBaseClientRetryAfterLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if requests == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`["subject"]`))
+	}))
+	defer server.Close()
+
+	c := NewBaseClient(WithURL(server.URL), WithBackoff(time.Millisecond, 10*time.Millisecond))
+
+	// retry after requested by server is limited to maximum backoff
+	start := time.Now()
+
+	subjects, err := c.GetSubjects(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"subject"}, subjects)
+	require.Equal(t, 2, requests)
+	require.True(t, time.Since(start) < time.Second)
+}
+
+func TestBaseClientDeleteRetry(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`[1]`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewBaseClient(WithURL(server.URL), WithRetries(2), WithBackoff(time.Millisecond, time.Millisecond))
+
+	// deletes, that might have been processed, are not retried
+	_, err := c.DeleteSubject(context.Background(), "subject", false)
+	require.True(t, errors.Is(err, ErrServerError))
+	require.Equal(t, 1, requests)
+
+	// deletes rejected with too many requests status are retried
+	status = http.StatusTooManyRequests
+	requests = 0
+
+	_, err = c.DeleteSchemaByVersion(context.Background(), "subject", 1, false)
+	require.Error(t, err)
+	require.Equal(t, 3, requests)
+
+	// deletes failing to connect are retried on next url
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	status = http.StatusOK
+	requests = 0
+	c = NewBaseClient(WithURL([]string{down.URL, server.URL}), WithBackoff(time.Millisecond, time.Millisecond))
+
+	versions, err := c.DeleteSubject(context.Background(), "subject", false)
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, versions)
+	require.Equal(t, 1, requests)
+}
+
+func TestBaseClientNoRetryOnClientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code": 40401, "message": "Subject not found"}`))
+	}))
+	defer server.Close()
+
+	c := NewBaseClient(WithURL(server.URL), WithBackoff(time.Millisecond, time.Millisecond))
+
+	_, err := c.GetSubjectVersions(context.Background(), "subject")
+	require.True(t, errors.Is(err, ErrSubjectNotFound))
+	require.Equal(t, 1, requests)
+}
+
 func TestGetSubjects(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject)
 
@@ -61,7 +242,7 @@ func TestGetSubjects(t *testing.T) {
 func TestGetSubjectVersions(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject)
 
@@ -80,12 +261,24 @@ func TestGetSubjectVersions(t *testing.T) {
 	versions, err := c.GetSubjectVersions(context.Background(), schema.Subject)
 	require.NoError(t, err)
 	require.Equal(t, []int{1, 2}, versions)
+
+	_, err = c.DeleteSchemaByVersion(context.Background(), schema.Subject, 2, false)
+	require.NoError(t, err)
+
+	versions, err = c.GetSubjectVersions(context.Background(), schema.Subject)
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, versions)
+
+	// soft deleted versions are listed with all versions
+	versions, err = c.GetAllSubjectVersions(context.Background(), schema.Subject)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, versions)
 }
 
 func TestGetSchemaByID(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject)
 
@@ -102,7 +295,7 @@ func TestGetSchemaByID(t *testing.T) {
 func TestGetSchemaByIDNotFound(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	_, err := c.GetSchemaByID(context.Background(), 999999)
 
@@ -112,7 +305,7 @@ func TestGetSchemaByIDNotFound(t *testing.T) {
 func TestGetLatestSchema(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject)
 
@@ -127,7 +320,7 @@ func TestGetLatestSchema(t *testing.T) {
 func TestGetSchemaByVersion(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject)
 
@@ -142,7 +335,7 @@ func TestGetSchemaByVersion(t *testing.T) {
 func TestGetSchemaSubjectVersions(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject, withRandomSchema)
 
@@ -161,10 +354,37 @@ func TestGetSchemaSubjectVersions(t *testing.T) {
 	require.Equal(t, map[string]int{schema1.Subject: schema1.Version, schema2.Subject: schema2.Version}, versions)
 }
 
+func TestGetReferencedBy(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	dep, err := c.CreateSchema(context.Background(), makeSchema(withRandomSubject, withRandomSchema))
+	require.NoError(t, err)
+
+	ids, err := c.GetReferencedBy(context.Background(), dep.Subject, dep.Version)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	schema := makeSchema(withRandomSubject)
+	schema.Schema = fmt.Sprintf(`syntax = "proto3"; import "%s.proto";`, dep.Subject)
+	schema.References = []Reference{{Name: dep.Subject + ".proto", Subject: dep.Subject, Version: dep.Version}}
+
+	schema, err = c.CreateSchema(context.Background(), schema)
+	require.NoError(t, err)
+
+	ids, err = c.GetReferencedBy(context.Background(), dep.Subject, dep.Version)
+	require.NoError(t, err)
+	require.Equal(t, []int{schema.ID}, ids)
+
+	_, err = c.GetReferencedBy(context.Background(), randomString(5), 1)
+	require.True(t, errors.Is(err, ErrSubjectNotFound))
+}
+
 func TestDeleteSubject(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject, withRandomSchema)
 
@@ -186,7 +406,7 @@ func TestDeleteSubject(t *testing.T) {
 func TestDeleteSubjectPermanent(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject, withRandomSchema)
 
@@ -208,7 +428,7 @@ func TestDeleteSubjectPermanent(t *testing.T) {
 func TestDeleteSchemaSubjectVersion(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject, withRandomSchema)
 
@@ -239,7 +459,7 @@ func TestDeleteSchemaSubjectVersion(t *testing.T) {
 func TestDeleteSchemaSubjectVersionPermanent(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject, withRandomSchema)
 
@@ -260,7 +480,7 @@ func TestDeleteSchemaSubjectVersionPermanent(t *testing.T) {
 func TestSchemaCompatible(t *testing.T) {
 	skipIntegration(t)
 
-	c := newTestBaseClient()
+	c := newTestBaseClient(t)
 
 	schema := makeSchema(withRandomSubject, withRandomSchema)
 
@@ -271,3 +491,291 @@ func TestSchemaCompatible(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, compatible)
 }
+
+func TestCheckSchemaCompatibility(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	server.SetCompatibilityChecker(func(subject, schema, previous string) []string {
+		if strings.Contains(schema, "int32 key") && strings.Contains(previous, "string key") {
+			return []string{"{errorType:'FIELD_KIND_CHANGED', description:'The kind of field 'key' changed'}"}
+		}
+
+		return nil
+	})
+
+	ctx := context.Background()
+	c := NewBaseClient(WithURL(server.URL))
+
+	schema1 := makeSchema(withRandomSubject)
+	schema1.Schema = `syntax = "proto3"; message Test { string key = 1; }`
+	_, err := c.CreateSchema(ctx, schema1)
+	require.NoError(t, err)
+
+	schema2 := makeSchema()
+	schema2.Subject = schema1.Subject
+	schema2.Schema = `syntax = "proto3"; message Test { bytes key = 1; }`
+	_, err = c.CreateSchema(ctx, schema2)
+	require.NoError(t, err)
+
+	schema := makeSchema()
+	schema.Subject = schema1.Subject
+	schema.Schema = `syntax = "proto3"; message Test { int32 key = 1; }`
+
+	// schema without version is checked against latest version
+	compatible, err := c.IsSchemaCompatible(ctx, schema)
+	require.NoError(t, err)
+	require.True(t, compatible)
+
+	result, err := c.CheckSchemaCompatibility(ctx, schema, false)
+	require.NoError(t, err)
+	require.True(t, result.IsCompatible)
+	require.Empty(t, result.Messages)
+
+	// non transitive compatibility checks only latest version
+	result, err = c.CheckSchemaCompatibility(ctx, schema, true)
+	require.NoError(t, err)
+	require.True(t, result.IsCompatible)
+
+	require.NoError(t, c.SetCompatibilityLevel(ctx, schema.Subject, BackwardTransitiveCompatibility))
+
+	result, err = c.CheckSchemaCompatibility(ctx, schema, true)
+	require.NoError(t, err)
+	require.False(t, result.IsCompatible)
+	require.Equal(t, []CompatibilityMessage{{
+		ErrorType:        "FIELD_KIND_CHANGED",
+		Description:      "The kind of field 'key' changed",
+		OldSchemaVersion: 1,
+		OldSchema:        schema1.Schema,
+		Compatibility:    BackwardTransitiveCompatibility,
+		Raw:              "{errorType:'FIELD_KIND_CHANGED', description:'The kind of field 'key' changed'}",
+	}}, result.Messages)
+
+	schema.Version = 1
+	compatible, err = c.IsSchemaCompatible(ctx, schema)
+	require.NoError(t, err)
+	require.False(t, compatible)
+
+	_, err = c.CheckSchemaCompatibility(ctx, makeSchema(withRandomSubject), false)
+	require.True(t, errors.Is(err, ErrSubjectNotFound))
+}
+
+func TestGlobalCompatibilityLevel(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	err := c.SetGlobalCompatibilityLevel(context.Background(), FullCompatibility)
+	require.NoError(t, err)
+
+	level, err := c.GetGlobalCompatibilityLevel(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, FullCompatibility, level)
+
+	level, err = c.DeleteGlobalCompatibilityLevel(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, FullCompatibility, level)
+
+	level, err = c.GetGlobalCompatibilityLevel(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, BackwardCompatibility, level)
+}
+
+func TestCompatibilityLevel(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	schema := makeSchema(withRandomSubject, withRandomSchema)
+
+	schema, err := c.CreateSchema(context.Background(), schema)
+	require.NoError(t, err)
+
+	_, err = c.GetCompatibilityLevel(context.Background(), schema.Subject, false)
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	global, err := c.GetGlobalCompatibilityLevel(context.Background())
+	require.NoError(t, err)
+
+	level, err := c.GetCompatibilityLevel(context.Background(), schema.Subject, true)
+	require.NoError(t, err)
+	require.Equal(t, global, level)
+
+	err = c.SetCompatibilityLevel(context.Background(), schema.Subject, FullTransitiveCompatibility)
+	require.NoError(t, err)
+
+	level, err = c.GetCompatibilityLevel(context.Background(), schema.Subject, false)
+	require.NoError(t, err)
+	require.Equal(t, FullTransitiveCompatibility, level)
+
+	level, err = c.DeleteCompatibilityLevel(context.Background(), schema.Subject)
+	require.NoError(t, err)
+	require.Equal(t, FullTransitiveCompatibility, level)
+
+	_, err = c.GetCompatibilityLevel(context.Background(), schema.Subject, false)
+	require.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestCompatibilityLevelInvalid(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	err := c.SetCompatibilityLevel(context.Background(), randomString(5), CompatibilityLevel("INVALID"))
+	require.Error(t, err)
+}
+
+func TestMode(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	subject := randomString(5)
+
+	global, err := c.GetGlobalMode(context.Background())
+	require.NoError(t, err)
+
+	_, err = c.GetMode(context.Background(), subject, false)
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	mode, err := c.GetMode(context.Background(), subject, true)
+	require.NoError(t, err)
+	require.Equal(t, global, mode)
+
+	err = c.SetMode(context.Background(), subject, ReadOnlyMode, false)
+	require.NoError(t, err)
+
+	mode, err = c.GetMode(context.Background(), subject, false)
+	require.NoError(t, err)
+	require.Equal(t, ReadOnlyMode, mode)
+
+	mode, err = c.DeleteMode(context.Background(), subject)
+	require.NoError(t, err)
+	require.Equal(t, ReadOnlyMode, mode)
+
+	err = c.SetMode(context.Background(), subject, Mode("INVALID"), false)
+	require.True(t, errors.Is(err, ErrInvalidMode))
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	schema := makeSchema(withRandomSubject, withRandomSchema)
+
+	err := c.SetMode(context.Background(), schema.Subject, ReadOnlyMode, false)
+	require.NoError(t, err)
+	defer c.DeleteMode(context.Background(), schema.Subject)
+
+	_, err = c.CreateSchema(context.Background(), schema)
+	require.True(t, errors.Is(err, ErrOperationNotPermitted))
+}
+
+func TestImportMode(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	schema := makeSchema(withRandomSubject, withRandomSchema)
+	schema.ID = 100000 + rand.Intn(100000)
+	schema.Version = 5
+
+	// schemas with explicit id can only be imported in import mode
+	_, err := c.ImportSchema(context.Background(), schema)
+	require.True(t, errors.Is(err, ErrOperationNotPermitted))
+
+	err = c.SetMode(context.Background(), schema.Subject, ImportMode, false)
+	require.NoError(t, err)
+	defer c.DeleteMode(context.Background(), schema.Subject)
+
+	created, err := c.ImportSchema(context.Background(), schema)
+	require.NoError(t, err)
+	require.Equal(t, schema.ID, created.ID)
+	require.Equal(t, schema.Version, created.Version)
+
+	result, err := c.GetSchemaByVersion(context.Background(), schema.Subject, schema.Version)
+	require.NoError(t, err)
+	require.Equal(t, schema.ID, result.ID)
+}
+
+func TestCreateFetchedSchema(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	created, err := c.CreateSchema(context.Background(), makeSchema(withRandomSubject, withRandomSchema))
+	require.NoError(t, err)
+
+	latest, err := c.GetLatestSchema(context.Background(), created.Subject)
+	require.NoError(t, err)
+
+	byVersion, err := c.GetSchemaByVersion(context.Background(), created.Subject, created.Version)
+	require.NoError(t, err)
+
+	// id and version of fetched schemas are ignored, when they are registered
+	// under other subject
+	for _, schema := range []*Schema{latest, byVersion} {
+		schema.Subject = randomString(5)
+
+		result, err := c.CreateSchema(context.Background(), schema)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, result.ID)
+		require.Equal(t, 1, result.Version)
+	}
+}
+
+func TestLookupSchema(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	schema := makeSchema(withRandomSubject, withRandomSchema)
+
+	_, err := c.LookupSchema(context.Background(), schema)
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	created, err := c.CreateSchema(context.Background(), schema)
+	require.NoError(t, err)
+
+	result, err := c.LookupSchema(context.Background(), schema)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, result.ID)
+	require.Equal(t, created.Version, result.Version)
+
+	schema.Schema = schema.Schema + `
+		message Foo {
+			string name = 1;
+		}
+	`
+
+	_, err = c.LookupSchema(context.Background(), schema)
+	require.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestRegistryErrors(t *testing.T) {
+	skipIntegration(t)
+
+	c := newTestBaseClient(t)
+
+	_, err := c.GetSchemaByID(context.Background(), 999999)
+	require.True(t, errors.Is(err, ErrSchemaNotFound))
+
+	_, err = c.GetLatestSchema(context.Background(), randomString(10))
+	require.True(t, errors.Is(err, ErrSubjectNotFound))
+
+	schema := makeSchema(withRandomSubject, withRandomSchema)
+
+	schema, err = c.CreateSchema(context.Background(), schema)
+	require.NoError(t, err)
+
+	_, err = c.GetSchemaByVersion(context.Background(), schema.Subject, schema.Version+1)
+	require.True(t, errors.Is(err, ErrVersionNotFound))
+
+	_, err = c.CreateSchema(context.Background(), &Schema{Subject: schema.Subject, Type: ProtobufSchemaType})
+	require.True(t, errors.Is(err, ErrInvalidSchema))
+
+	var registryErr *RegistryError
+	require.True(t, errors.As(err, &registryErr))
+	require.Equal(t, 422, registryErr.StatusCode)
+}
@@ -0,0 +1,265 @@
+package srclient
+
+import (
+	"sync"
+
+	"github.com/goburrow/cache"
+)
+
+// CacheKind is kind of entry cached by CachingClient
+type CacheKind int
+
+const (
+	// SubjectsCacheKind is kind of list of subjects
+	SubjectsCacheKind CacheKind = iota
+
+	// SubjectVersionsCacheKind is kind of lists of subject versions
+	SubjectVersionsCacheKind
+
+	// LatestSchemaCacheKind is kind of latest subject schemas
+	LatestSchemaCacheKind
+
+	// SchemaByVersionCacheKind is kind of schemas by subject version
+	SchemaByVersionCacheKind
+
+	// SchemaByIDCacheKind is kind of schemas by id
+	SchemaByIDCacheKind
+
+	// SchemaValueCacheKind is kind of schemas by schema value, used to
+	// lookup already created schemas
+	SchemaValueCacheKind
+
+	// CompatibilityLevelCacheKind is kind of global and subject
+	// compatibility levels
+	CompatibilityLevelCacheKind
+)
+
+// Immutable returns whether entries of kind never change, unless schemas
+// are deleted
+func (k CacheKind) Immutable() bool {
+	return k == SchemaByIDCacheKind || k == SchemaValueCacheKind
+}
+
+func (k CacheKind) String() string {
+	switch k {
+	case SubjectsCacheKind:
+		return "subjects"
+	case SubjectVersionsCacheKind:
+		return "subject versions"
+	case LatestSchemaCacheKind:
+		return "latest schema"
+	case SchemaByVersionCacheKind:
+		return "schema by version"
+	case SchemaByIDCacheKind:
+		return "schema by id"
+	case SchemaValueCacheKind:
+		return "schema value"
+	case CompatibilityLevelCacheKind:
+		return "compatibility level"
+	}
+
+	return "unknown"
+}
+
+// CacheBackend stores entries cached by CachingClient
+//
+// Keys are unique across kinds, and values are opaque to backend. Entries
+// must not be returned after they are invalidated, backends are free to
+// evict entries at any time.
+type CacheBackend interface {
+	// Get gets value cached under key
+	Get(kind CacheKind, key string) (interface{}, bool)
+
+	// Put caches value under key
+	Put(kind CacheKind, key string, val interface{})
+
+	// Invalidate removes value cached under key
+	Invalidate(kind CacheKind, key string)
+}
+
+// InspectableCacheBackend is cache backend, that can iterate over cached
+// entries and count evicted entries, so they are included in cache statistics
+type InspectableCacheBackend interface {
+	CacheBackend
+
+	// Range calls fn for each cached entry, until fn returns false
+	Range(fn func(kind CacheKind, key string, val interface{}) bool)
+
+	// Evictions returns number of entries of kind evicted or expired by
+	// backend
+	Evictions(kind CacheKind) uint64
+}
+
+// goburrowEntry wraps values stored in goburrow cache, so invalidated
+// entries can be replaced with empty entries, as cache requires values under
+// the same key to be of the same type and puts made shortly after
+// invalidation can be lost
+type goburrowEntry struct {
+	kind  CacheKind
+	value interface{}
+}
+
+type goburrowCacheBackend struct {
+	mutable   cache.Cache
+	immutable cache.Cache
+
+	// entries are cached entries, tracked as goburrow cache can not be
+	// iterated and removes entries asynchronously
+	mu        sync.Mutex
+	entries   map[string]*goburrowEntry
+	evictions [numCacheKinds]uint64
+}
+
+// NewGoburrowCacheBackend creates cache backend, that stores mutable and
+// immutable entries in separate goburrow caches created with options
+func NewGoburrowCacheBackend(mutable, immutable []cache.Option) InspectableCacheBackend {
+	b := newGoburrowCacheBackend()
+	b.mutable = cache.New(append(mutable, cache.WithRemovalListener(b.onRemoval))...)
+	b.immutable = cache.New(append(immutable, cache.WithRemovalListener(b.onRemoval))...)
+
+	return b
+}
+
+func newGoburrowCacheBackend() *goburrowCacheBackend {
+	return &goburrowCacheBackend{entries: map[string]*goburrowEntry{}}
+}
+
+func (b *goburrowCacheBackend) cache(kind CacheKind) cache.Cache {
+	if kind.Immutable() {
+		return b.immutable
+	}
+
+	return b.mutable
+}
+
+func (b *goburrowCacheBackend) Get(kind CacheKind, key string) (interface{}, bool) {
+	v, exists := b.cache(kind).GetIfPresent(key)
+	if !exists {
+		return nil, false
+	}
+
+	entry := v.(*goburrowEntry)
+	return entry.value, entry.value != nil
+}
+
+func (b *goburrowCacheBackend) Put(kind CacheKind, key string, val interface{}) {
+	entry := &goburrowEntry{kind: kind, value: val}
+
+	b.mu.Lock()
+	b.entries[key] = entry
+	b.mu.Unlock()
+
+	b.cache(kind).Put(key, entry)
+}
+
+func (b *goburrowCacheBackend) Invalidate(kind CacheKind, key string) {
+	b.mu.Lock()
+	delete(b.entries, key)
+	b.mu.Unlock()
+
+	if _, exists := b.cache(kind).GetIfPresent(key); exists {
+		b.cache(kind).Put(key, &goburrowEntry{kind: kind})
+	}
+}
+
+func (b *goburrowCacheBackend) Range(fn func(kind CacheKind, key string, val interface{}) bool) {
+	b.mu.Lock()
+	entries := make(map[string]*goburrowEntry, len(b.entries))
+	for key, entry := range b.entries {
+		entries[key] = entry
+	}
+	b.mu.Unlock()
+
+	for key, entry := range entries {
+		if !fn(entry.kind, key, entry.value) {
+			return
+		}
+	}
+}
+
+func (b *goburrowCacheBackend) Evictions(kind CacheKind) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.evictions[kind]
+}
+
+// onRemoval removes entries evicted or expired by goburrow cache, ignoring
+// invalidated and replaced entries
+func (b *goburrowCacheBackend) onRemoval(k cache.Key, v cache.Value) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := k.(string)
+	if entry, ok := b.entries[key]; ok && entry == v {
+		delete(b.entries, key)
+		b.evictions[entry.kind]++
+	}
+}
+
+type mapEntry struct {
+	kind  CacheKind
+	value interface{}
+}
+
+// MapCacheBackend is cache backend, that stores entries in map without
+// limits, making it deterministic and suitable for tests
+type MapCacheBackend struct {
+	mu      sync.Mutex
+	entries map[string]mapEntry
+}
+
+// NewMapCacheBackend creates map cache backend
+func NewMapCacheBackend() *MapCacheBackend {
+	return &MapCacheBackend{entries: map[string]mapEntry{}}
+}
+
+func (b *MapCacheBackend) Get(kind CacheKind, key string) (interface{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, exists := b.entries[key]
+	return entry.value, exists
+}
+
+func (b *MapCacheBackend) Put(kind CacheKind, key string, val interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[key] = mapEntry{kind: kind, value: val}
+}
+
+func (b *MapCacheBackend) Invalidate(kind CacheKind, key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.entries, key)
+}
+
+func (b *MapCacheBackend) Range(fn func(kind CacheKind, key string, val interface{}) bool) {
+	b.mu.Lock()
+	entries := make(map[string]mapEntry, len(b.entries))
+	for key, entry := range b.entries {
+		entries[key] = entry
+	}
+	b.mu.Unlock()
+
+	for key, entry := range entries {
+		if !fn(entry.kind, key, entry.value) {
+			return
+		}
+	}
+}
+
+// Evictions returns zero, as map cache backend never evicts entries
+func (b *MapCacheBackend) Evictions(kind CacheKind) uint64 {
+	return 0
+}
+
+// Len returns number of cached entries
+func (b *MapCacheBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.entries)
+}
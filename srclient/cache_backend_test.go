@@ -0,0 +1,77 @@
+package srclient
+
+import (
+	"testing"
+
+	"github.com/goburrow/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheBackends(t *testing.T) {
+	backends := map[string]func() CacheBackend{
+		"goburrow": func() CacheBackend {
+			return NewGoburrowCacheBackend(nil, nil)
+		},
+		"map": func() CacheBackend {
+			return NewMapCacheBackend()
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend()
+
+			_, exists := b.Get(SubjectsCacheKind, "key")
+			require.False(t, exists)
+
+			b.Put(SubjectsCacheKind, "key", "value")
+			b.Put(SchemaByIDCacheKind, "id", 1)
+
+			val, exists := b.Get(SubjectsCacheKind, "key")
+			require.True(t, exists)
+			require.Equal(t, "value", val)
+
+			val, exists = b.Get(SchemaByIDCacheKind, "id")
+			require.True(t, exists)
+			require.Equal(t, 1, val)
+
+			// invalidated entries are not returned and can be cached again
+			b.Invalidate(SubjectsCacheKind, "key")
+
+			_, exists = b.Get(SubjectsCacheKind, "key")
+			require.False(t, exists)
+
+			b.Put(SubjectsCacheKind, "key", "value2")
+
+			val, exists = b.Get(SubjectsCacheKind, "key")
+			require.True(t, exists)
+			require.Equal(t, "value2", val)
+
+			b.Invalidate(SchemaByIDCacheKind, "id")
+
+			entries := map[string]interface{}{}
+			b.(InspectableCacheBackend).Range(func(kind CacheKind, key string, val interface{}) bool {
+				require.Equal(t, SubjectsCacheKind, kind)
+				entries[key] = val
+				return true
+			})
+			require.Equal(t, map[string]interface{}{"key": "value2"}, entries)
+		})
+	}
+}
+
+func TestGoburrowCacheBackendKinds(t *testing.T) {
+	mutable, immutable := cache.New(), cache.New()
+
+	b := newGoburrowCacheBackend()
+	b.mutable, b.immutable = mutable, immutable
+
+	for kind := SubjectsCacheKind; kind <= CompatibilityLevelCacheKind; kind++ {
+		b.Put(kind, kind.String(), kind)
+
+		_, inMutable := mutable.GetIfPresent(kind.String())
+		_, inImmutable := immutable.GetIfPresent(kind.String())
+		require.Equal(t, !kind.Immutable(), inMutable, kind.String())
+		require.Equal(t, kind.Immutable(), inImmutable, kind.String())
+	}
+}
@@ -0,0 +1,111 @@
+package srclient
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// numCacheKinds is number of kinds of cached entries
+const numCacheKinds = int(CompatibilityLevelCacheKind) + 1
+
+// CacheStats are statistics of entries of one kind cached by CachingClient
+type CacheStats struct {
+	// Hits is number of reads served from cache
+	Hits uint64
+
+	// Misses is number of reads not served from cache
+	Misses uint64
+
+	// Loads is number of values loaded from schema registry, where
+	// concurrent reads of the same value are loaded once
+	Loads uint64
+
+	// LoadErrors is number of loads, that failed
+	LoadErrors uint64
+
+	// Evictions is number of entries evicted or expired by cache backend
+	Evictions uint64
+
+	// Entries is number of cached entries, including expired entries kept to
+	// be served stale
+	Entries int
+}
+
+// cacheCounters counts reads and loads of cached entries
+type cacheCounters struct {
+	hits       [numCacheKinds]uint64
+	misses     [numCacheKinds]uint64
+	loads      [numCacheKinds]uint64
+	loadErrors [numCacheKinds]uint64
+}
+
+func (c *cacheCounters) recordRead(kind CacheKind, hit bool) {
+	if hit {
+		atomic.AddUint64(&c.hits[kind], 1)
+	} else {
+		atomic.AddUint64(&c.misses[kind], 1)
+	}
+}
+
+func (c *cacheCounters) recordLoad(kind CacheKind, err error) {
+	atomic.AddUint64(&c.loads[kind], 1)
+
+	if err != nil {
+		atomic.AddUint64(&c.loadErrors[kind], 1)
+	}
+}
+
+// Stats returns statistics of cached entries by kind. Evictions and entries
+// are only counted if cache backend implements InspectableCacheBackend.
+func (c *CachingClient) Stats() map[CacheKind]CacheStats {
+	stats := map[CacheKind]CacheStats{}
+	for kind := CacheKind(0); int(kind) < numCacheKinds; kind++ {
+		stats[kind] = CacheStats{
+			Hits:       atomic.LoadUint64(&c.counters.hits[kind]),
+			Misses:     atomic.LoadUint64(&c.counters.misses[kind]),
+			Loads:      atomic.LoadUint64(&c.counters.loads[kind]),
+			LoadErrors: atomic.LoadUint64(&c.counters.loadErrors[kind]),
+		}
+	}
+
+	backend, ok := c.cache.backend.(InspectableCacheBackend)
+	if !ok {
+		return stats
+	}
+
+	for kind, keys := range c.CacheKeys() {
+		s := stats[kind]
+		s.Entries = len(keys)
+		stats[kind] = s
+	}
+
+	for kind, s := range stats {
+		s.Evictions = backend.Evictions(kind)
+		stats[kind] = s
+	}
+
+	return stats
+}
+
+// CacheKeys returns sorted keys of cached entries by kind, useful for
+// debugging. Format of keys is not stable, and keys are only returned if
+// cache backend implements InspectableCacheBackend.
+func (c *CachingClient) CacheKeys() map[CacheKind][]string {
+	keys := map[CacheKind][]string{}
+
+	backend, ok := c.cache.backend.(InspectableCacheBackend)
+	if !ok {
+		return keys
+	}
+
+	backend.Range(func(kind CacheKind, key string, val interface{}) bool {
+		keys[kind] = append(keys[kind], key)
+		return true
+	})
+
+	for _, k := range keys {
+		sort.Strings(k)
+	}
+
+	return keys
+}
@@ -2,9 +2,12 @@ package srclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hash/fnv"
+	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/goburrow/cache"
@@ -16,6 +19,25 @@ const (
 	cacheKeySchemaByVersion = "version/%s/%d"
 	cacheKeySchemaLatest    = "version/%s/latest"
 	cacheKeySchemaVersions  = "versions/%s"
+	cacheKeyGlobalConfig    = "config"
+	cacheKeySubjectConfig   = "config/%s"
+	cacheKeySchemaValue     = "value/%d"
+)
+
+// keys of coalesced calls, that are not cached
+const (
+	callKeyLookupSchema          = "lookup/%d"
+	callKeySchemaSubjectVersions = "id/%d/versions"
+	callKeySchemaVersions        = "id/%d/allversions"
+	callKeyReferencedBy          = "referencedby/%s/%d"
+	callKeyAllSubjectVersions    = "allversions/%s"
+	callKeyGlobalMode            = "mode"
+	callKeySubjectMode           = "mode/%s/%t"
+)
+
+const (
+	defaultRefreshTimeout = 10 * time.Second
+	defaultStaleWait      = 200 * time.Millisecond
 )
 
 type cachableSchema struct {
@@ -49,19 +71,137 @@ func (c *cachableSchema) Sum64() uint64 {
 	return h.Sum64()
 }
 
+// cacheEntry wraps cached values with their expiration
+type cacheEntry struct {
+	value interface{}
+
+	// expires is time when entry expires, or zero if entry does not expire
+	expires time.Time
+}
+
+// subjectIndex indexes entries cached for subject, so they can be
+// invalidated when subject or subject version is deleted
+type subjectIndex struct {
+	// keys are keys of subject versions, latest schema and schemas by version
+	// mapped to their kinds
+	keys map[string]CacheKind
+
+	// values are hashes of cached schema values mapped to subject versions
+	values map[uint64]int
+
+	// ids are schema ids mapped by subject versions
+	ids map[int]int
+}
+
 type cacheHelper struct {
-	cache            cache.Cache
-	infcache         cache.Cache
+	backend          CacheBackend
 	cacheSchemaValue bool
+
+	// expiration is ttl of mutable entries without ttl set
+	expiration time.Duration
+	ttl        CacheTTL
+	now        func() time.Time
+
+	// refreshBefore is duration before expiration, when entries are
+	// refreshed in background
+	refreshBefore time.Duration
+
+	// maxStaleness is duration after expiration, for which entries are
+	// served when they cannot be refreshed
+	maxStaleness time.Duration
+
+	// disk persists immutable entries, if persistent cache is enabled
+	disk *diskCache
+
+	mu       sync.Mutex
+	subjects map[string]*subjectIndex
 }
 
 type cacheFunc func(val interface{})
 
+// entry gets cache entry, including expired entries
+func (c *cacheHelper) entry(kind CacheKind, key string) (*cacheEntry, bool) {
+	v, exists := c.backend.Get(kind, key)
+	if !exists {
+		return nil, false
+	}
+
+	entry, ok := v.(*cacheEntry)
+	return entry, ok
+}
+
+// get gets value from cache, ignoring expired entries
+func (c *cacheHelper) get(kind CacheKind, key string) (interface{}, bool) {
+	entry, exists := c.entry(kind, key)
+	if !exists || !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// put caches value for ttl, where zero ttl caches value until it is evicted
+func (c *cacheHelper) put(kind CacheKind, key string, val interface{}, ttl time.Duration) {
+	entry := &cacheEntry{value: val}
+	if ttl > 0 {
+		entry.expires = c.now().Add(ttl)
+	}
+
+	c.backend.Put(kind, key, entry)
+}
+
+// update updates cached value, keeping its expiration
+func (c *cacheHelper) update(kind CacheKind, key string, val interface{}) {
+	if entry, exists := c.entry(kind, key); exists {
+		c.backend.Put(kind, key, &cacheEntry{value: val, expires: entry.expires})
+	}
+}
+
+func (c *cacheHelper) invalidate(kind CacheKind, key string) {
+	c.backend.Invalidate(kind, key)
+}
+
+// needsRefresh returns whether cached entry expires in less than refresh
+// duration and should be refreshed in background
+func (c *cacheHelper) needsRefresh(kind CacheKind, key string) bool {
+	if c.refreshBefore <= 0 {
+		return false
+	}
+
+	entry, exists := c.entry(kind, key)
+	return exists && !entry.expires.IsZero() && !c.now().Before(entry.expires.Add(-c.refreshBefore))
+}
+
+// stale gets expired value from cache and time since it expired, if it
+// expired less than maximum staleness ago
+func (c *cacheHelper) stale(kind CacheKind, key string) (interface{}, time.Duration, bool) {
+	entry, exists := c.entry(kind, key)
+	if !exists || entry.expires.IsZero() {
+		return nil, 0, false
+	}
+
+	age := c.now().Sub(entry.expires)
+	if age < 0 || age >= c.maxStaleness {
+		return nil, 0, false
+	}
+
+	return entry.value, age, true
+}
+
+// mutableTTL returns ttl of mutable entries, that default to expiration
+func (c *cacheHelper) mutableTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 {
+		return ttl
+	}
+
+	return c.expiration
+}
+
 func (c *cacheHelper) GetSubjects() ([]string, cacheFunc) {
-	cacheFunc := c.defaultCacheFunc(cacheKeySubjects)
+	cacheFunc := c.defaultCacheFunc(SubjectsCacheKind, cacheKeySubjects, c.mutableTTL(c.ttl.Subjects))
 
 	val := []string{}
-	if v, exists := c.cache.GetIfPresent(cacheKeySubjects); exists {
+	if v, exists := c.get(SubjectsCacheKind, cacheKeySubjects); exists {
 		val = v.([]string)
 	}
 
@@ -70,10 +210,10 @@ func (c *cacheHelper) GetSubjects() ([]string, cacheFunc) {
 
 func (c *cacheHelper) GetSchemaVersions(subject string) ([]int, cacheFunc) {
 	key := fmt.Sprintf(cacheKeySchemaVersions, subject)
-	cacheFunc := c.defaultCacheFunc(key)
+	cacheFunc := c.subjectCacheFunc(SubjectVersionsCacheKind, subject, key, c.mutableTTL(c.ttl.SubjectVersions))
 
 	val := []int{}
-	if v, exists := c.cache.GetIfPresent(key); exists {
+	if v, exists := c.get(SubjectVersionsCacheKind, key); exists {
 		val = v.([]int)
 	}
 
@@ -82,82 +222,353 @@ func (c *cacheHelper) GetSchemaVersions(subject string) ([]int, cacheFunc) {
 
 func (c *cacheHelper) GetSchemaByID(schemaID int) (*Schema, cacheFunc) {
 	key := fmt.Sprintf(cacheKeySchemaByID, schemaID)
-	return c.cacheSchema(key, c.infcache, false)
+	return c.cacheSchema(SchemaByIDCacheKind, key, false)
 }
 
 func (c *cacheHelper) GetSchemaByVersion(subject string, version int) (*Schema, cacheFunc) {
 	key := fmt.Sprintf(cacheKeySchemaByVersion, subject, version)
-	return c.cacheSchema(key, c.cache, false)
+	return c.cacheSchema(SchemaByVersionCacheKind, key, false)
 }
 
 func (c *cacheHelper) GetLatestSchema(subject string) (*Schema, cacheFunc) {
 	key := fmt.Sprintf(cacheKeySchemaLatest, subject)
-	return c.cacheSchema(key, c.cache, true)
+	return c.cacheSchema(LatestSchemaCacheKind, key, true)
 }
 
 func (c *cacheHelper) GetSchemaValue(schema *Schema) (*Schema, cacheFunc) {
 	if c.cacheSchemaValue {
-		return c.cacheSchema(cachableSchemaFromSchema(schema).Sum64(), c.infcache, false)
+		return c.cacheSchema(SchemaValueCacheKind, fmt.Sprintf(cacheKeySchemaValue, cachableSchemaFromSchema(schema).Sum64()), false)
 	}
 
 	return nil, c.schemaCacheFunc
 }
 
-func (c *cacheHelper) InvalidateSubject(subject string, parmanent bool) {
-	c.cache.InvalidateAll()
-	c.infcache.InvalidateAll()
-	return
+func (c *cacheHelper) GetGlobalCompatibilityLevel() (CompatibilityLevel, cacheFunc) {
+	cacheFunc := c.defaultCacheFunc(CompatibilityLevelCacheKind, cacheKeyGlobalConfig, c.expiration)
+
+	var val CompatibilityLevel
+	if v, exists := c.get(CompatibilityLevelCacheKind, cacheKeyGlobalConfig); exists {
+		val = v.(CompatibilityLevel)
+	}
+
+	return val, cacheFunc
+}
+
+// GetCompatibilityLevel gets cached subject compatibility level, where empty
+// level means subject has no compatibility level configured
+func (c *cacheHelper) GetCompatibilityLevel(subject string) (CompatibilityLevel, bool, cacheFunc) {
+	key := fmt.Sprintf(cacheKeySubjectConfig, subject)
+	cacheFunc := c.defaultCacheFunc(CompatibilityLevelCacheKind, key, c.expiration)
+
+	var val CompatibilityLevel
+	v, exists := c.get(CompatibilityLevelCacheKind, key)
+	if exists {
+		val = v.(CompatibilityLevel)
+	}
+
+	return val, exists, cacheFunc
+}
+
+func (c *cacheHelper) InvalidateGlobalCompatibilityLevel() {
+	c.invalidate(CompatibilityLevelCacheKind, cacheKeyGlobalConfig)
+}
+
+func (c *cacheHelper) InvalidateCompatibilityLevel(subject string) {
+	c.invalidate(CompatibilityLevelCacheKind, fmt.Sprintf(cacheKeySubjectConfig, subject))
+}
+
+// InvalidateSubject invalidates entries cached for subject, and if subject is
+// permanently deleted also schemas by id, that are not used by other subjects
+func (c *cacheHelper) InvalidateSubject(subject string, permanent bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.disk != nil {
+		c.disk.RemoveSubject(subject)
+	}
+
+	c.invalidate(SubjectsCacheKind, cacheKeySubjects)
+	c.invalidate(SubjectVersionsCacheKind, fmt.Sprintf(cacheKeySchemaVersions, subject))
+	c.invalidate(LatestSchemaCacheKind, fmt.Sprintf(cacheKeySchemaLatest, subject))
+
+	index, ok := c.subjects[subject]
+	if !ok {
+		return
+	}
+
+	for key, kind := range index.keys {
+		c.invalidate(kind, key)
+	}
+
+	for hash := range index.values {
+		c.invalidate(SchemaValueCacheKind, fmt.Sprintf(cacheKeySchemaValue, hash))
+	}
+
+	index.keys = map[string]CacheKind{}
+	index.values = map[uint64]int{}
+
+	if permanent {
+		delete(c.subjects, subject)
+
+		for _, id := range index.ids {
+			c.invalidateUnusedSchemaID(id)
+		}
+
+		c.invalidate(CompatibilityLevelCacheKind, fmt.Sprintf(cacheKeySubjectConfig, subject))
+	}
 }
 
-func (c *cacheHelper) InvalidateSchemaByVersion(subject string, version int, parmanent bool) {
-	if parmanent {
-		c.cache.InvalidateAll()
-		c.infcache.InvalidateAll()
+// InvalidateSchemaByVersion invalidates entries cached for subject version,
+// and if version is permanently deleted also schema by id, if it is not used
+// by other subject versions
+func (c *cacheHelper) InvalidateSchemaByVersion(subject string, version int, permanent bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versionKey := fmt.Sprintf(cacheKeySchemaByVersion, subject, version)
+	latestKey := fmt.Sprintf(cacheKeySchemaLatest, subject)
+	versionsKey := fmt.Sprintf(cacheKeySchemaVersions, subject)
+
+	c.invalidate(SchemaByVersionCacheKind, versionKey)
+	c.invalidate(LatestSchemaCacheKind, latestKey)
+	c.invalidate(SubjectVersionsCacheKind, versionsKey)
+	c.invalidate(SubjectsCacheKind, cacheKeySubjects)
+
+	if c.disk != nil {
+		c.disk.RemoveSchemaByVersion(subject, version)
+	}
+
+	index, ok := c.subjects[subject]
+	if !ok {
 		return
 	}
 
-	c.cache.Invalidate(fmt.Sprintf(cacheKeySchemaByVersion, subject, version))
-	c.cache.Invalidate(fmt.Sprintf(cacheKeySchemaLatest, subject))
+	delete(index.keys, versionKey)
+	delete(index.keys, latestKey)
+	delete(index.keys, versionsKey)
+
+	// deleted schema is registered as new version when created again
+	for hash, v := range index.values {
+		if v == version {
+			c.invalidate(SchemaValueCacheKind, fmt.Sprintf(cacheKeySchemaValue, hash))
+			delete(index.values, hash)
+		}
+	}
+
+	if id, ok := index.ids[version]; ok && permanent {
+		delete(index.ids, version)
+		c.invalidateUnusedSchemaID(id)
+	}
 }
 
-func (c *cacheHelper) defaultCacheFunc(key string) cacheFunc {
+// CacheCreatedSchema caches created schema and updates cached subjects,
+// subject versions and latest subject schema
+func (c *cacheHelper) CacheCreatedSchema(schema *Schema) {
+	c.schemaCacheFunc(schema)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versionsKey := fmt.Sprintf(cacheKeySchemaVersions, schema.Subject)
+	latestKey := fmt.Sprintf(cacheKeySchemaLatest, schema.Subject)
+
+	// created version is unknown, so dependent entries cannot be updated
+	if schema.Version == 0 {
+		c.invalidate(SubjectVersionsCacheKind, versionsKey)
+		c.invalidate(LatestSchemaCacheKind, latestKey)
+		c.invalidate(SubjectsCacheKind, cacheKeySubjects)
+		return
+	}
+
+	if v, exists := c.get(SubjectVersionsCacheKind, versionsKey); exists {
+		if versions := v.([]int); !containsInt(versions, schema.Version) {
+			c.update(SubjectVersionsCacheKind, versionsKey, insertSorted(versions, schema.Version))
+		}
+	}
+
+	if v, exists := c.get(LatestSchemaCacheKind, latestKey); exists && v.(*Schema).Version < schema.Version {
+		c.update(LatestSchemaCacheKind, latestKey, schema)
+	}
+
+	if v, exists := c.get(SubjectsCacheKind, cacheKeySubjects); exists && !containsString(v.([]string), schema.Subject) {
+		c.update(SubjectsCacheKind, cacheKeySubjects, append(append([]string{}, v.([]string)...), schema.Subject))
+	}
+}
+
+// index returns index of subject, must be called with lock held
+func (c *cacheHelper) index(subject string) *subjectIndex {
+	if c.subjects == nil {
+		c.subjects = map[string]*subjectIndex{}
+	}
+
+	index, ok := c.subjects[subject]
+	if !ok {
+		index = &subjectIndex{
+			keys:   map[string]CacheKind{},
+			values: map[uint64]int{},
+			ids:    map[int]int{},
+		}
+		c.subjects[subject] = index
+	}
+
+	return index
+}
+
+// putSubjectKey caches value for subject, must be called with lock held
+func (c *cacheHelper) putSubjectKey(kind CacheKind, subject string, key string, val interface{}, ttl time.Duration) {
+	c.put(kind, key, val, ttl)
+	c.index(subject).keys[key] = kind
+}
+
+// invalidateUnusedSchemaID invalidates schema by id, if it is not used by
+// any of indexed subjects, must be called with lock held
+func (c *cacheHelper) invalidateUnusedSchemaID(id int) {
+	for _, index := range c.subjects {
+		for _, usedID := range index.ids {
+			if usedID == id {
+				return
+			}
+		}
+	}
+
+	c.invalidate(SchemaByIDCacheKind, fmt.Sprintf(cacheKeySchemaByID, id))
+
+	if c.disk != nil {
+		c.disk.RemoveSchemaByID(id)
+	}
+}
+
+func (c *cacheHelper) defaultCacheFunc(kind CacheKind, key string, ttl time.Duration) cacheFunc {
+	return func(val interface{}) {
+		c.put(kind, key, val, ttl)
+	}
+}
+
+func (c *cacheHelper) subjectCacheFunc(kind CacheKind, subject string, key string, ttl time.Duration) cacheFunc {
 	return func(val interface{}) {
-		c.cache.Put(key, val)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.putSubjectKey(kind, subject, key, val, ttl)
 	}
 }
 
 func (c *cacheHelper) schemaCacheFunc(val interface{}) {
 	schema := val.(*Schema)
 
+	c.cacheSchemaEntries(schema)
+
+	if c.disk != nil {
+		c.disk.Store(schema)
+	}
+}
+
+// cacheSchemaEntries caches schema under schema id, subject version and
+// schema value
+func (c *cacheHelper) cacheSchemaEntries(schema *Schema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cacheSchemaByIDAndVersion(schema)
+
+	if c.cacheSchemaValue {
+		hash := cachableSchemaFromSchema(schema).Sum64()
+		c.put(SchemaValueCacheKind, fmt.Sprintf(cacheKeySchemaValue, hash), schema, 0)
+
+		if schema.Subject != "" {
+			c.index(schema.Subject).values[hash] = schema.Version
+		}
+	}
+}
+
+// cachePersistedSchema caches schema loaded from persistent cache by id and
+// subject version, but not by value, as subject might have been deleted
+// since schema was persisted and creating schema must reach schema registry
+func (c *cacheHelper) cachePersistedSchema(schema *Schema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cacheSchemaByIDAndVersion(schema)
+}
+
+// cacheSchemaByIDAndVersion must be called with lock held
+func (c *cacheHelper) cacheSchemaByIDAndVersion(schema *Schema) {
 	// if schema id is set, cache schema under schema by id key
 	if schema.ID > 0 {
-		c.infcache.Put(fmt.Sprintf(cacheKeySchemaByID, schema.ID), schema)
+		c.put(SchemaByIDCacheKind, fmt.Sprintf(cacheKeySchemaByID, schema.ID), schema, c.ttl.SchemaByID)
 	}
 
 	// if schema subject and version is set, cache schema under schema by version key
 	if schema.Subject != "" && schema.Version > 0 {
-		c.cache.Put(fmt.Sprintf(cacheKeySchemaByVersion, schema.Subject, schema.Version), schema)
-	}
+		c.putSubjectKey(SchemaByVersionCacheKind, schema.Subject, fmt.Sprintf(cacheKeySchemaByVersion, schema.Subject, schema.Version), schema, c.expiration)
 
-	if c.cacheSchemaValue {
-		c.infcache.Put(cachableSchemaFromSchema(schema).Sum64(), schema)
+		if schema.ID > 0 {
+			c.index(schema.Subject).ids[schema.Version] = schema.ID
+		}
 	}
 }
 
-func (c *cacheHelper) cacheSchema(key interface{}, cache cache.Cache, latest bool) (*Schema, cacheFunc) {
+func (c *cacheHelper) cacheSchema(kind CacheKind, key string, latest bool) (*Schema, cacheFunc) {
 	var val *Schema
-	if v, exists := cache.GetIfPresent(key); exists {
+	if v, exists := c.get(kind, key); exists {
 		val = v.(*Schema)
 	}
 
 	return val, func(val interface{}) {
 		schema := val.(*Schema)
 
-		c.cache.Put(fmt.Sprintf(cacheKeySchemaLatest, schema.Subject), schema)
+		if latest {
+			c.subjectCacheFunc(LatestSchemaCacheKind, schema.Subject, fmt.Sprintf(cacheKeySchemaLatest, schema.Subject), c.mutableTTL(c.ttl.LatestSchema))(schema)
+		}
+
 		c.schemaCacheFunc(val)
 	}
 }
 
+// CacheTTL configures for how long entries of each kind are cached. Zero ttl
+// of mutable entries defaults to expiration set with WithExpiration, while
+// schemas by id are cached until evicted.
+type CacheTTL struct {
+	// Subjects is ttl of list of subjects
+	Subjects time.Duration
+
+	// SubjectVersions is ttl of lists of subject versions
+	SubjectVersions time.Duration
+
+	// LatestSchema is ttl of latest subject schemas
+	LatestSchema time.Duration
+
+	// SchemaByID is ttl of schemas by id
+	SchemaByID time.Duration
+}
+
+// CacheLimit limits size of cache, where zero values mean no limit
+type CacheLimit struct {
+	// MaxEntries is maximum number of cached entries
+	MaxEntries int
+
+	// MaxBytes is approximate maximum size of cached entries in bytes, when
+	// exceeded least recently used entries are evicted regardless of
+	// eviction policy
+	MaxBytes int64
+}
+
+// EvictionPolicy is policy used to evict entries, when cache has maximum
+// number of entries
+type EvictionPolicy string
+
+const (
+	// LRUEviction evicts least recently used entries
+	LRUEviction EvictionPolicy = "lru"
+
+	// SLRUEviction evicts entries using segmented LRU
+	SLRUEviction EvictionPolicy = "slru"
+
+	// TinyLFUEviction evicts entries using window TinyLFU, that admits
+	// entries based on their frequency of use
+	TinyLFUEviction EvictionPolicy = "tinylfu"
+)
+
 type CachingClientOption func(*CachingClient)
 
 func (CachingClientOption) OptionType() {}
@@ -165,7 +576,98 @@ func (CachingClientOption) OptionType() {}
 // WithExpiration set expiration for mutable entries like list of subjects
 func WithExpiration(time time.Duration) CachingClientOption {
 	return func(c *CachingClient) {
-		c.expiration = time
+		c.cache.expiration = time
+	}
+}
+
+// WithTTL sets ttl of subjects, subject versions, latest schemas and schemas
+// by id
+func WithTTL(ttl CacheTTL) CachingClientOption {
+	return func(c *CachingClient) {
+		c.cache.ttl = ttl
+	}
+}
+
+// WithBackgroundRefresh refreshes cached entries in background, when they
+// are read less than before ahead of their expiration, so reads of
+// frequently used entries do not wait for schema registry
+func WithBackgroundRefresh(before time.Duration) CachingClientOption {
+	return func(c *CachingClient) {
+		c.cache.refreshBefore = before
+	}
+}
+
+// WithServeStale returns expired entries that expired less than
+// maxStaleness ago when schema registry is unavailable. Expired entries are
+// returned together with StaleError, which can be matched with ErrStale.
+func WithServeStale(maxStaleness time.Duration) CachingClientOption {
+	return func(c *CachingClient) {
+		c.cache.maxStaleness = maxStaleness
+	}
+}
+
+// WithRefreshTimeout sets timeout of loads made in background, like
+// background refreshes and loads of expired entries served as stale, which
+// is 10 seconds by default. Reads of entries, that are not cached, wait for
+// background loads, so timeout also limits how long they can be blocked by
+// unresponsive schema registry.
+func WithRefreshTimeout(timeout time.Duration) CachingClientOption {
+	return func(c *CachingClient) {
+		c.refreshTimeout = timeout
+	}
+}
+
+// WithStaleWait sets how long reads of expired entries, that can be served
+// as stale, wait for schema registry, before expired values are returned,
+// which is 200 milliseconds by default. Loads continue in background and
+// update cache once they complete. Expired values are returned with
+// StaleError only if last load failed or did not complete within refresh
+// timeout.
+func WithStaleWait(wait time.Duration) CachingClientOption {
+	return func(c *CachingClient) {
+		c.staleWait = wait
+	}
+}
+
+// WithPersistentCache persists schemas by id and subject versions to
+// directory and loads them when client is created, so they are available
+// after restart even if schema registry is unavailable. When size of
+// persisted schemas exceeds maxBytes, oldest schemas are removed, where zero
+// maxBytes means no limit. Like failed writes, failure to open directory
+// does not fail client, which then caches schemas only in memory.
+func WithPersistentCache(dir string, maxBytes int64) CachingClientOption {
+	return func(c *CachingClient) {
+		c.persistDir = dir
+		c.persistMaxBytes = maxBytes
+	}
+}
+
+// WithCacheBackend sets backend used to store cached entries, instead of
+// goburrow caches. Cache limits and eviction policy only apply to default
+// backend, and backend must implement InspectableCacheBackend for cached
+// entries to be included in statistics.
+func WithCacheBackend(backend CacheBackend) CachingClientOption {
+	return func(c *CachingClient) {
+		c.backend = backend
+	}
+}
+
+// WithCacheLimits limits size of cache of mutable entries, like subject
+// versions and latest schemas, and size of cache of immutable entries, like
+// schemas by id and schema values
+func WithCacheLimits(mutable, immutable CacheLimit) CachingClientOption {
+	return func(c *CachingClient) {
+		c.mutableLimit = mutable
+		c.immutableLimit = immutable
+	}
+}
+
+// WithEvictionPolicy sets policy used to evict entries from caches with
+// maximum number of entries, by default least recently used entries are
+// evicted
+func WithEvictionPolicy(policy EvictionPolicy) CachingClientOption {
+	return func(c *CachingClient) {
+		c.policy = policy
 	}
 }
 
@@ -176,6 +678,15 @@ func WithSchemaValueCaching(enable ...bool) CachingClientOption {
 	}
 }
 
+// WithNegativeCaching enables caching of not found errors of schemas by id,
+// subject versions and latest subject schemas. Not found errors cached for
+// subject or schema id are removed when schema is created using this client.
+func WithNegativeCaching(ttl NegativeCacheTTL) CachingClientOption {
+	return func(c *CachingClient) {
+		c.negative.ttl = ttl
+	}
+}
+
 var defaultCachingClientOpts = []CachingClientOption{
 	WithSchemaValueCaching(),
 }
@@ -185,8 +696,26 @@ var defaultCachingClientOpts = []CachingClientOption{
 type CachingClient struct {
 	client Client
 	cache  cacheHelper
+	group  flightGroup
 
-	expiration time.Duration
+	counters cacheCounters
+
+	negative negativeCache
+
+	mutableLimit   CacheLimit
+	immutableLimit CacheLimit
+	policy         EvictionPolicy
+
+	persistDir      string
+	persistMaxBytes int64
+
+	backend CacheBackend
+
+	// refreshTimeout is timeout of background loads
+	refreshTimeout time.Duration
+
+	// staleWait is how long reads of stale entries wait for loads
+	staleWait time.Duration
 }
 
 // NewCachingClient creates a new client with caching support
@@ -195,7 +724,13 @@ func NewCachingClient(client Client, opts ...CachingClientOption) *CachingClient
 		panic("client must be set")
 	}
 
-	c := &CachingClient{client: client}
+	c := &CachingClient{
+		client:         client,
+		refreshTimeout: defaultRefreshTimeout,
+		staleWait:      defaultStaleWait,
+	}
+	c.cache.now = time.Now
+	c.negative.now = time.Now
 
 	// apply default caching client options
 	for _, opt := range defaultCachingClientOpts {
@@ -207,28 +742,186 @@ func NewCachingClient(client Client, opts ...CachingClientOption) *CachingClient
 		opt(c)
 	}
 
-	// initialize cache
-	if c.expiration > 0 {
-		c.cache.cache = cache.New(cache.WithExpireAfterWrite(c.expiration))
-	} else {
-		c.cache.cache = cache.New()
+	switch c.policy {
+	case "", LRUEviction, SLRUEviction, TinyLFUEviction:
+	default:
+		panic(fmt.Errorf("invalid eviction policy: %s", c.policy))
+	}
+
+	if c.refreshTimeout <= 0 {
+		panic(fmt.Errorf("invalid refresh timeout: %s", c.refreshTimeout))
 	}
 
-	// initialize infinity cache
-	c.cache.infcache = cache.New()
+	if c.staleWait < 0 {
+		panic(fmt.Errorf("invalid stale wait: %s", c.staleWait))
+	}
+
+	// initialize cache, where entries are removed after the longest ttl of
+	// mutable entries, if all of them expire, and maximum staleness
+	expiration := c.cache.expiration
+	if expiration > 0 {
+		for _, ttl := range []time.Duration{c.cache.ttl.Subjects, c.cache.ttl.SubjectVersions, c.cache.ttl.LatestSchema} {
+			if ttl > expiration {
+				expiration = ttl
+			}
+		}
+
+		expiration += c.cache.maxStaleness
+	}
+
+	// initialize cache backend, where immutable entries are stored in
+	// infinity cache
+	c.cache.backend = c.backend
+	if c.cache.backend == nil {
+		backend := newGoburrowCacheBackend()
+		backend.mutable = newCache(c.mutableLimit, c.policy, expiration, backend.onRemoval)
+		backend.immutable = newCache(c.immutableLimit, c.policy, 0, backend.onRemoval)
+		c.cache.backend = backend
+	}
+
+	// load persisted schemas, falling back to memory only cache if
+	// persistent cache cannot be opened
+	if c.persistDir != "" {
+		if disk, schemas, err := openDiskCache(c.persistDir, c.persistMaxBytes); err == nil {
+			for _, schema := range schemas {
+				c.cache.cachePersistedSchema(schema)
+			}
+
+			c.cache.disk = disk
+		}
+	}
 
 	return c
 }
 
+// newCache creates cache with size limit, eviction policy and expiration,
+// calling removal listener for entries removed by cache
+func newCache(limit CacheLimit, policy EvictionPolicy, expiration time.Duration, removalListener cache.Func) cache.Cache {
+	opts := []cache.Option{}
+
+	if limit.MaxEntries > 0 {
+		opts = append(opts, cache.WithMaximumSize(limit.MaxEntries))
+	}
+
+	if policy != "" {
+		opts = append(opts, cache.WithPolicy(string(policy)))
+	}
+
+	if expiration > 0 {
+		opts = append(opts, cache.WithExpireAfterWrite(expiration))
+	}
+
+	if limit.MaxBytes > 0 {
+		return newSizedCache(limit.MaxBytes, &goburrowEntry{}, removalListener, opts...)
+	}
+
+	return cache.New(append(opts, cache.WithRemovalListener(removalListener))...)
+}
+
+type loadFunc func(ctx context.Context) (interface{}, error)
+
+// cachedLoad returns function, that loads value and caches it
+func cachedLoad(cache cacheFunc, fn loadFunc) loadFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		val, err := fn(ctx)
+		if err == nil && cache != nil {
+			cache(val)
+		}
+
+		return val, err
+	}
+}
+
+// load loads value using upstream client and caches it, coalescing
+// concurrent loads with the same key into a single upstream call
+func (c *CachingClient) load(ctx context.Context, key string, cache cacheFunc, fn loadFunc) (interface{}, error) {
+	return c.group.Do(ctx, key, cachedLoad(cache, fn))
+}
+
+// countedLoad returns function, that loads value and records load of entry
+// of kind
+func (c *CachingClient) countedLoad(kind CacheKind, fn loadFunc) loadFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		val, err := fn(ctx)
+		c.counters.recordLoad(kind, err)
+
+		return val, err
+	}
+}
+
+// loadEntry loads value of entry cached under key like load, returning
+// expired value of entry with StaleError if schema registry is unavailable
+//
+// Entries with expired value are loaded in background and their reads only
+// wait stale wait duration for the load. Expired value is returned without
+// error while load is in flight, unless last load of entry failed, like when
+// schema registry did not respond within refresh timeout.
+func (c *CachingClient) loadEntry(ctx context.Context, kind CacheKind, key string, cacheFn cacheFunc, fn loadFunc) (interface{}, error) {
+	load := cachedLoad(cacheFn, c.countedLoad(kind, fn))
+
+	stale, age, ok := c.cache.stale(kind, key)
+	if !ok {
+		return c.group.Do(ctx, key, load)
+	}
+
+	val, err := c.group.GoWait(ctx, key, c.refreshTimeout, c.staleWait, load)
+	if errors.Is(err, errWaitTimeout) {
+		// schema registry is slow, but has not failed
+		return stale, nil
+	}
+
+	if err != nil && isRetryable(ctx, err) {
+		return stale, &StaleError{Err: err, Age: age}
+	}
+
+	return val, err
+}
+
+// revalidate refreshes entry cached under key in background, if it expires
+// soon
+func (c *CachingClient) revalidate(kind CacheKind, key string, cacheFn cacheFunc, fn loadFunc) {
+	if c.cache.needsRefresh(kind, key) {
+		c.group.Go(key, c.refreshTimeout, cachedLoad(cacheFn, c.countedLoad(kind, fn)))
+	}
+}
+
+// loadSchema loads schema like loadEntry, caching not found errors for ttl
+func (c *CachingClient) loadSchema(ctx context.Context, kind CacheKind, key string, ttl time.Duration, subject string, id int, cacheFn cacheFunc, fn loadFunc) (*Schema, error) {
+	gen, err := c.negative.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := c.loadEntry(ctx, kind, key, cacheFn, func(ctx context.Context) (interface{}, error) {
+		val, err := fn(ctx)
+		if errors.Is(err, ErrNotFound) {
+			c.negative.Put(key, ttl, gen, subject, id, err)
+		}
+
+		return val, err
+	})
+	schema, _ := val.(*Schema)
+
+	return schema, err
+}
+
 // GetSubjects gets a list of defines subjects
 func (c *CachingClient) GetSubjects(ctx context.Context) (subjects []string, err error) {
 	var cache cacheFunc
 
+	load := func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSubjects(ctx)
+	}
+
 	if subjects, cache = c.cache.GetSubjects(); len(subjects) == 0 {
-		subjects, err = c.client.GetSubjects(ctx)
-		if err == nil {
-			cache(subjects)
-		}
+		c.counters.recordRead(SubjectsCacheKind, false)
+
+		var val interface{}
+		val, err = c.loadEntry(ctx, SubjectsCacheKind, cacheKeySubjects, cache, load)
+		subjects, _ = val.([]string)
+	} else {
+		c.counters.recordRead(SubjectsCacheKind, true)
+		c.revalidate(SubjectsCacheKind, cacheKeySubjects, cache, load)
 	}
 
 	return
@@ -237,24 +930,50 @@ func (c *CachingClient) GetSubjects(ctx context.Context) (subjects []string, err
 func (c *CachingClient) GetSubjectVersions(ctx context.Context, subject string) (versions []int, err error) {
 	var cache cacheFunc
 
+	key := fmt.Sprintf(cacheKeySchemaVersions, subject)
+	load := func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSubjectVersions(ctx, subject)
+	}
+
 	if versions, cache = c.cache.GetSchemaVersions(subject); len(versions) == 0 {
-		versions, err = c.client.GetSubjectVersions(ctx, subject)
-		if err == nil {
-			cache(versions)
-		}
+		c.counters.recordRead(SubjectVersionsCacheKind, false)
+
+		var val interface{}
+		val, err = c.loadEntry(ctx, SubjectVersionsCacheKind, key, cache, load)
+		versions, _ = val.([]int)
+	} else {
+		c.counters.recordRead(SubjectVersionsCacheKind, true)
+		c.revalidate(SubjectVersionsCacheKind, key, cache, load)
 	}
 
 	return
 }
 
+// GetAllSubjectVersions gets versions of subject including soft deleted
+// versions, which are not cached
+func (c *CachingClient) GetAllSubjectVersions(ctx context.Context, subject string) ([]int, error) {
+	val, err := c.load(ctx, fmt.Sprintf(callKeyAllSubjectVersions, subject), nil, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetAllSubjectVersions(ctx, subject)
+	})
+	versions, _ := val.([]int)
+
+	return versions, err
+}
+
 func (c *CachingClient) GetSchemaByID(ctx context.Context, schemaID int) (schema *Schema, err error) {
 	var cache cacheFunc
 
+	key := fmt.Sprintf(cacheKeySchemaByID, schemaID)
+	load := func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSchemaByID(ctx, schemaID)
+	}
+
 	if schema, cache = c.cache.GetSchemaByID(schemaID); schema == nil {
-		schema, err = c.client.GetSchemaByID(ctx, schemaID)
-		if err == nil {
-			cache(schema)
-		}
+		c.counters.recordRead(SchemaByIDCacheKind, false)
+		schema, err = c.loadSchema(ctx, SchemaByIDCacheKind, key, c.negative.ttl.SchemaByID, "", schemaID, cache, load)
+	} else {
+		c.counters.recordRead(SchemaByIDCacheKind, true)
+		c.revalidate(SchemaByIDCacheKind, key, cache, load)
 	}
 
 	return
@@ -263,11 +982,17 @@ func (c *CachingClient) GetSchemaByID(ctx context.Context, schemaID int) (schema
 func (c *CachingClient) GetSchemaByVersion(ctx context.Context, subject string, version int) (schema *Schema, err error) {
 	var cache cacheFunc
 
+	key := fmt.Sprintf(cacheKeySchemaByVersion, subject, version)
+	load := func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSchemaByVersion(ctx, subject, version)
+	}
+
 	if schema, cache = c.cache.GetSchemaByVersion(subject, version); schema == nil {
-		schema, err = c.client.GetSchemaByVersion(ctx, subject, version)
-		if err == nil {
-			cache(schema)
-		}
+		c.counters.recordRead(SchemaByVersionCacheKind, false)
+		schema, err = c.loadSchema(ctx, SchemaByVersionCacheKind, key, c.negative.ttl.SchemaByVersion, subject, 0, cache, load)
+	} else {
+		c.counters.recordRead(SchemaByVersionCacheKind, true)
+		c.revalidate(SchemaByVersionCacheKind, key, cache, load)
 	}
 
 	return
@@ -276,36 +1001,86 @@ func (c *CachingClient) GetSchemaByVersion(ctx context.Context, subject string,
 func (c *CachingClient) GetLatestSchema(ctx context.Context, subject string) (schema *Schema, err error) {
 	var cache cacheFunc
 
+	key := fmt.Sprintf(cacheKeySchemaLatest, subject)
+	load := func(ctx context.Context) (interface{}, error) {
+		return c.client.GetLatestSchema(ctx, subject)
+	}
+
 	if schema, cache = c.cache.GetLatestSchema(subject); schema == nil {
-		schema, err = c.client.GetLatestSchema(ctx, subject)
-		if err == nil {
-			cache(schema)
-		}
+		c.counters.recordRead(LatestSchemaCacheKind, false)
+		schema, err = c.loadSchema(ctx, LatestSchemaCacheKind, key, c.negative.ttl.LatestSchema, subject, 0, cache, load)
+	} else {
+		c.counters.recordRead(LatestSchemaCacheKind, true)
+		c.revalidate(LatestSchemaCacheKind, key, cache, load)
 	}
 
 	return
 }
 
 func (c *CachingClient) CreateSchema(ctx context.Context, schema *Schema) (createdSchema *Schema, err error) {
-	var cache cacheFunc
+	createdSchema, _ = c.cache.GetSchemaValue(schema)
+	c.counters.recordRead(SchemaValueCacheKind, createdSchema != nil)
 
-	if createdSchema, cache = c.cache.GetSchemaValue(schema); createdSchema == nil {
+	if createdSchema == nil {
 		createdSchema, err = c.client.CreateSchema(ctx, schema)
 		if err == nil {
-			cache(schema)
+			c.negative.Invalidate(schema.Subject, createdSchema.ID)
+			c.cache.CacheCreatedSchema(createdSchema)
+		}
+	}
+
+	return
+}
+
+// ImportSchema imports schema under subject, sharing schema value cache with
+// CreateSchema, where cached schema is only used if it has schema ID and
+// version of imported schema
+func (c *CachingClient) ImportSchema(ctx context.Context, schema *Schema) (importedSchema *Schema, err error) {
+	importedSchema, _ = c.cache.GetSchemaValue(schema)
+	if importedSchema != nil && (schema.ID > 0 && schema.ID != importedSchema.ID || schema.Version > 0 && schema.Version != importedSchema.Version) {
+		importedSchema = nil
+	}
+
+	c.counters.recordRead(SchemaValueCacheKind, importedSchema != nil)
+
+	if importedSchema == nil {
+		importedSchema, err = c.client.ImportSchema(ctx, schema)
+		if err == nil {
+			c.negative.Invalidate(schema.Subject, importedSchema.ID)
+			c.cache.CacheCreatedSchema(importedSchema)
 		}
 	}
 
 	return
 }
 
+// LookupSchema looks up schema under subject, sharing schema value cache
+// with CreateSchema
+func (c *CachingClient) LookupSchema(ctx context.Context, schema *Schema) (foundSchema *Schema, err error) {
+	var cache cacheFunc
+
+	foundSchema, cache = c.cache.GetSchemaValue(schema)
+	c.counters.recordRead(SchemaValueCacheKind, foundSchema != nil)
+
+	if foundSchema == nil {
+		var val interface{}
+		key := fmt.Sprintf(callKeyLookupSchema, cachableSchemaFromSchema(schema).Sum64())
+		val, err = c.load(ctx, key, cache, c.countedLoad(SchemaValueCacheKind, func(ctx context.Context) (interface{}, error) {
+			return c.client.LookupSchema(ctx, schema)
+		}))
+		foundSchema, _ = val.(*Schema)
+	}
+
+	return
+}
+
 func (c *CachingClient) DeleteSubject(ctx context.Context, subject string, permanent bool) ([]int, error) {
-	c.cache.InvalidateSubject(subject, permanent)
+	defer c.cache.InvalidateSubject(subject, permanent)
 	return c.client.DeleteSubject(ctx, subject, permanent)
 }
 
 func (c *CachingClient) DeleteSchemaByVersion(ctx context.Context, subject string, version int, permanent bool) (int, error) {
-	c.cache.InvalidateSchemaByVersion(subject, version, permanent)
+	defer c.cache.InvalidateSchemaByVersion(subject, version, permanent)
 	return c.client.DeleteSchemaByVersion(ctx, subject, version, permanent)
 }
 
@@ -313,6 +1088,141 @@ func (c *CachingClient) IsSchemaCompatible(ctx context.Context, schema *Schema)
 	return c.client.IsSchemaCompatible(ctx, schema)
 }
 
+func (c *CachingClient) CheckSchemaCompatibility(ctx context.Context, schema *Schema, allVersions bool) (*CompatibilityResult, error) {
+	return c.client.CheckSchemaCompatibility(ctx, schema, allVersions)
+}
+
 func (c *CachingClient) GetSchemaSubjectVersions(ctx context.Context, schemaID int) (map[string]int, error) {
-	return c.client.GetSchemaSubjectVersions(ctx, schemaID)
+	val, err := c.load(ctx, fmt.Sprintf(callKeySchemaSubjectVersions, schemaID), nil, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSchemaSubjectVersions(ctx, schemaID)
+	})
+	versions, _ := val.(map[string]int)
+
+	return versions, err
+}
+
+// GetSchemaVersions gets all subject versions registered with schema, which
+// are not cached
+func (c *CachingClient) GetSchemaVersions(ctx context.Context, schemaID int) ([]SubjectVersion, error) {
+	val, err := c.load(ctx, fmt.Sprintf(callKeySchemaVersions, schemaID), nil, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetSchemaVersions(ctx, schemaID)
+	})
+	versions, _ := val.([]SubjectVersion)
+
+	return versions, err
+}
+
+func (c *CachingClient) GetReferencedBy(ctx context.Context, subject string, version int) ([]int, error) {
+	val, err := c.load(ctx, fmt.Sprintf(callKeyReferencedBy, subject, version), nil, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetReferencedBy(ctx, subject, version)
+	})
+	ids, _ := val.([]int)
+
+	return ids, err
+}
+
+func (c *CachingClient) GetGlobalCompatibilityLevel(ctx context.Context) (level CompatibilityLevel, err error) {
+	var cache cacheFunc
+
+	level, cache = c.cache.GetGlobalCompatibilityLevel()
+	c.counters.recordRead(CompatibilityLevelCacheKind, level != "")
+
+	if level == "" {
+		var val interface{}
+		val, err = c.load(ctx, cacheKeyGlobalConfig, cache, c.countedLoad(CompatibilityLevelCacheKind, func(ctx context.Context) (interface{}, error) {
+			return c.client.GetGlobalCompatibilityLevel(ctx)
+		}))
+		level, _ = val.(CompatibilityLevel)
+	}
+
+	return
+}
+
+func (c *CachingClient) SetGlobalCompatibilityLevel(ctx context.Context, level CompatibilityLevel) error {
+	defer c.cache.InvalidateGlobalCompatibilityLevel()
+	return c.client.SetGlobalCompatibilityLevel(ctx, level)
+}
+
+func (c *CachingClient) DeleteGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error) {
+	defer c.cache.InvalidateGlobalCompatibilityLevel()
+	return c.client.DeleteGlobalCompatibilityLevel(ctx)
+}
+
+// GetCompatibilityLevel gets subject compatibility level. Subject level is
+// cached separately from global level, so changes of global level are
+// reflected for subjects without compatibility level configured.
+func (c *CachingClient) GetCompatibilityLevel(ctx context.Context, subject string, defaultToGlobal bool) (CompatibilityLevel, error) {
+	level, exists, cache := c.cache.GetCompatibilityLevel(subject)
+	c.counters.recordRead(CompatibilityLevelCacheKind, exists)
+
+	if !exists {
+		val, err := c.load(ctx, fmt.Sprintf(cacheKeySubjectConfig, subject), cache, c.countedLoad(CompatibilityLevelCacheKind, func(ctx context.Context) (interface{}, error) {
+			level, err := c.client.GetCompatibilityLevel(ctx, subject, false)
+			if errors.Is(err, ErrNotFound) {
+				level, err = "", nil
+			}
+
+			return level, err
+		}))
+
+		if err != nil {
+			return "", err
+		}
+
+		level = val.(CompatibilityLevel)
+	}
+
+	if level != "" {
+		return level, nil
+	}
+
+	if defaultToGlobal {
+		return c.GetGlobalCompatibilityLevel(ctx)
+	}
+
+	return "", &RegistryError{
+		StatusCode: http.StatusNotFound,
+		ErrorCode:  errCodeSubjectLevelCompatNotConfigured,
+		Message:    fmt.Sprintf("Subject '%s' does not have subject-level compatibility configured", subject),
+	}
+}
+
+func (c *CachingClient) SetCompatibilityLevel(ctx context.Context, subject string, level CompatibilityLevel) error {
+	defer c.cache.InvalidateCompatibilityLevel(subject)
+	return c.client.SetCompatibilityLevel(ctx, subject, level)
+}
+
+func (c *CachingClient) DeleteCompatibilityLevel(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	defer c.cache.InvalidateCompatibilityLevel(subject)
+	return c.client.DeleteCompatibilityLevel(ctx, subject)
+}
+
+func (c *CachingClient) GetGlobalMode(ctx context.Context) (Mode, error) {
+	val, err := c.load(ctx, callKeyGlobalMode, nil, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetGlobalMode(ctx)
+	})
+	mode, _ := val.(Mode)
+
+	return mode, err
+}
+
+func (c *CachingClient) SetGlobalMode(ctx context.Context, mode Mode, force bool) error {
+	return c.client.SetGlobalMode(ctx, mode, force)
+}
+
+func (c *CachingClient) GetMode(ctx context.Context, subject string, defaultToGlobal bool) (Mode, error) {
+	val, err := c.load(ctx, fmt.Sprintf(callKeySubjectMode, subject, defaultToGlobal), nil, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetMode(ctx, subject, defaultToGlobal)
+	})
+	mode, _ := val.(Mode)
+
+	return mode, err
+}
+
+func (c *CachingClient) SetMode(ctx context.Context, subject string, mode Mode, force bool) error {
+	return c.client.SetMode(ctx, subject, mode, force)
+}
+
+func (c *CachingClient) DeleteMode(ctx context.Context, subject string) (Mode, error) {
+	return c.client.DeleteMode(ctx, subject)
 }
@@ -4,28 +4,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"github.com/xtruder/go-kafka-protobuf/srclient/srtest"
 )
 
 var _ Client = (*CachingClient)(nil)
 
+// checkSchemaCache checks that schema is served from cache by id, version
+// and schema value
 func checkSchemaCache(t *testing.T, client *CachingClient, schema *Schema) {
-	val, present := client.cache.infcache.GetIfPresent(fmt.Sprintf(cacheKeySchemaByID, schema.ID))
-	require.True(t, present)
+	ctx := context.Background()
+	stats := client.Stats()
+
+	val, err := client.GetSchemaByID(ctx, schema.ID)
+	require.NoError(t, err)
 	require.Equal(t, schema, val)
 
 	if schema.Version > 0 {
-		val, present = client.cache.cache.GetIfPresent(fmt.Sprintf(cacheKeySchemaByVersion, schema.Subject, schema.Version))
-		require.True(t, present)
+		val, err = client.GetSchemaByVersion(ctx, schema.Subject, schema.Version)
+		require.NoError(t, err)
 		require.Equal(t, schema, val)
 	}
 
-	val, present = client.cache.infcache.GetIfPresent(cachableSchemaFromSchema(schema).Sum64())
-	require.True(t, present)
+	val, err = client.LookupSchema(ctx, schema)
+	require.NoError(t, err)
 	require.Equal(t, schema, val)
+
+	for kind, s := range client.Stats() {
+		require.Equal(t, stats[kind].Misses, s.Misses, kind.String())
+	}
 }
 
 func TestCachingClientGetSubjects(t *testing.T) {
@@ -43,9 +58,7 @@ func TestCachingClientGetSubjects(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, subjects, result)
 
-	val, present := cc.cache.cache.GetIfPresent("subjects")
-	require.True(t, present)
-	require.EqualValues(t, val, subjects)
+	requireCached(t, cc, true, "subjects")
 
 	result, err = cc.GetSubjects(ctx)
 	require.NoError(t, err)
@@ -65,8 +78,7 @@ func TestCachingClientGetSubjectsError(t *testing.T) {
 	_, err := cc.GetSubjects(ctx)
 	require.Error(t, err)
 
-	_, present := cc.cache.cache.GetIfPresent("subjects")
-	require.False(t, present)
+	requireCached(t, cc, false, "subjects")
 }
 
 func TestCachingClientSchemaVersions(t *testing.T) {
@@ -86,9 +98,7 @@ func TestCachingClientSchemaVersions(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, versions, result)
 
-	val, present := cc.cache.cache.GetIfPresent("versions/" + subject)
-	require.True(t, present)
-	require.EqualValues(t, val, versions)
+	requireCached(t, cc, true, "versions/"+subject)
 
 	result, err = cc.GetSubjectVersions(ctx, subject)
 	require.NoError(t, err)
@@ -110,8 +120,7 @@ func TestCachingClientSchemaVersionsError(t *testing.T) {
 	_, err := cc.GetSubjectVersions(ctx, subject)
 	require.Error(t, err)
 
-	_, present := cc.cache.cache.GetIfPresent("versions/" + subject)
-	require.False(t, present)
+	requireCached(t, cc, false, "versions/"+subject)
 }
 
 func TestCachingClientSchemaByID(t *testing.T) {
@@ -206,30 +215,116 @@ func TestCachingClientCreateSchema(t *testing.T) {
 	require.Equal(t, schema, createdSchema)
 }
 
-func TestCachingClientDeleteSubject(t *testing.T) {
+func TestCachingClientImportSchema(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	c := NewMockClient(ctrl)
 	ctx := context.Background()
 
-	subject := "subject"
-	versions := []int{1}
-	c.EXPECT().DeleteSubject(ctx, subject, true).Return(versions, nil)
+	schema := &Schema{Subject: "subject", Schema: "schema", ID: 1, Version: 1}
+	c.EXPECT().CreateSchema(ctx, schema).Times(1).Return(schema, nil)
 
 	cc := NewCachingClient(c)
-	cc.cache.cache.Put("key", "value")
-	cc.cache.infcache.Put("key", "value")
 
-	resultVersions, err := cc.DeleteSubject(ctx, subject, true)
+	_, err := cc.CreateSchema(ctx, schema)
+	require.NoError(t, err)
+
+	// cached schema is used, if it has imported id and version
+	importedSchema, err := cc.ImportSchema(ctx, schema)
 	require.NoError(t, err)
-	require.EqualValues(t, versions, resultVersions)
+	require.Equal(t, schema, importedSchema)
 
-	_, exists := cc.cache.cache.GetIfPresent("key")
-	require.False(t, exists)
+	imported := &Schema{Subject: "subject", Schema: "schema", ID: 2, Version: 2}
+	c.EXPECT().ImportSchema(ctx, imported).Times(1).Return(imported, nil)
 
-	_, exists = cc.cache.infcache.GetIfPresent("key")
-	require.False(t, exists)
+	importedSchema, err = cc.ImportSchema(ctx, imported)
+	require.NoError(t, err)
+	require.Equal(t, imported, importedSchema)
+}
+
+// warmSubjectsCache caches subjects s1 and s2, where s1 has versions with
+// schema ids 1 and 2, and s2 has version with schema id 1
+func warmSubjectsCache(t *testing.T, c *MockClient, cc *CachingClient) {
+	ctx := context.Background()
+
+	schemas := []*Schema{
+		{Subject: "s1", Version: 1, ID: 1, Schema: "a"},
+		{Subject: "s1", Version: 2, ID: 2, Schema: "b"},
+		{Subject: "s2", Version: 1, ID: 1, Schema: "a"},
+	}
+
+	c.EXPECT().GetSubjects(ctx).Return([]string{"s1", "s2"}, nil)
+	_, err := cc.GetSubjects(ctx)
+	require.NoError(t, err)
+
+	for _, schema := range schemas {
+		c.EXPECT().GetSchemaByVersion(ctx, schema.Subject, schema.Version).Return(schema, nil)
+		_, err := cc.GetSchemaByVersion(ctx, schema.Subject, schema.Version)
+		require.NoError(t, err)
+	}
+
+	for _, latest := range []*Schema{schemas[1], schemas[2]} {
+		versions := []int{1, 2}[:latest.Version]
+
+		c.EXPECT().GetSubjectVersions(ctx, latest.Subject).Return(versions, nil)
+		_, err := cc.GetSubjectVersions(ctx, latest.Subject)
+		require.NoError(t, err)
+
+		c.EXPECT().GetLatestSchema(ctx, latest.Subject).Return(latest, nil)
+		_, err = cc.GetLatestSchema(ctx, latest.Subject)
+		require.NoError(t, err)
+	}
+}
+
+func requireCached(t *testing.T, cc *CachingClient, cached bool, keys ...string) {
+	cachedKeys := []string{}
+	for _, k := range cc.CacheKeys() {
+		cachedKeys = append(cachedKeys, k...)
+	}
+
+	for _, key := range keys {
+		require.Equal(t, cached, containsString(cachedKeys, key), "key %v", key)
+	}
+}
+
+func schemaValueKey(subject string, schema string) string {
+	return fmt.Sprintf(cacheKeySchemaValue, cachableSchemaFromSchema(&Schema{Subject: subject, Schema: schema}).Sum64())
+}
+
+func TestCachingClientDeleteSubject(t *testing.T) {
+	ctx := context.Background()
+
+	for _, permanent := range []bool{false, true} {
+		t.Run(fmt.Sprintf("permanent=%t", permanent), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := NewMockClient(ctrl)
+			cc := NewCachingClient(c)
+			warmSubjectsCache(t, c, cc)
+
+			c.EXPECT().DeleteSubject(ctx, "s1", permanent).Return([]int{1, 2}, nil)
+
+			resultVersions, err := cc.DeleteSubject(ctx, "s1", permanent)
+			require.NoError(t, err)
+			require.EqualValues(t, []int{1, 2}, resultVersions)
+
+			// entries of deleted subject are invalidated
+			requireCached(t, cc, false,
+				"subjects", "versions/s1", "version/s1/latest", "version/s1/1", "version/s1/2")
+			requireCached(t, cc, false,
+				schemaValueKey("s1", "a"), schemaValueKey("s1", "b"))
+
+			// entries of other subjects are kept
+			requireCached(t, cc, true, "versions/s2", "version/s2/latest", "version/s2/1")
+			requireCached(t, cc, true, "id/1", schemaValueKey("s2", "a"))
+
+			// schema ids are invalidated only if subject is permanently
+			// deleted and schema is not used by other subjects
+			requireCached(t, cc, !permanent, "id/2")
+		})
+	}
 }
 
 func TestCachingClientDeleteSchemaByVersion(t *testing.T) {
@@ -241,24 +336,27 @@ func TestCachingClientDeleteSchemaByVersion(t *testing.T) {
 
 	subject := "subject"
 	version := 1
-	c.EXPECT().DeleteSchemaByVersion(ctx, subject, version, false).Return(version, nil)
+	schema := &Schema{Subject: subject, Version: version, ID: 1, Schema: "schema"}
 
-	ckSchemaByVersion := fmt.Sprintf(cacheKeySchemaByVersion, subject, version)
-	ckSchemaLatests := fmt.Sprintf(cacheKeySchemaLatest, subject)
+	c.EXPECT().GetSchemaByVersion(ctx, subject, version).Return(schema, nil)
+	c.EXPECT().GetLatestSchema(ctx, subject).Return(schema, nil)
+	c.EXPECT().DeleteSchemaByVersion(ctx, subject, version, false).Return(version, nil)
 
 	cc := NewCachingClient(c)
-	cc.cache.cache.Put(ckSchemaByVersion, "value")
-	cc.cache.cache.Put(ckSchemaLatests, "value")
+
+	_, err := cc.GetSchemaByVersion(ctx, subject, version)
+	require.NoError(t, err)
+
+	_, err = cc.GetLatestSchema(ctx, subject)
+	require.NoError(t, err)
+
+	requireCached(t, cc, true, "version/subject/1", "version/subject/latest")
 
 	resultVersion, err := cc.DeleteSchemaByVersion(ctx, subject, version, false)
 	require.NoError(t, err)
 	require.EqualValues(t, version, resultVersion)
 
-	_, exists := cc.cache.cache.GetIfPresent(ckSchemaByVersion)
-	require.False(t, exists)
-
-	_, exists = cc.cache.cache.GetIfPresent(ckSchemaLatests)
-	require.False(t, exists)
+	requireCached(t, cc, false, "version/subject/1", "version/subject/latest")
 }
 
 func TestCachingClientDeleteSchemaByVersionPersistent(t *testing.T) {
@@ -268,23 +366,30 @@ func TestCachingClientDeleteSchemaByVersionPersistent(t *testing.T) {
 	c := NewMockClient(ctrl)
 	ctx := context.Background()
 
-	subject := "subject"
-	version := 1
-	c.EXPECT().DeleteSchemaByVersion(ctx, subject, version, true).Return(version, nil)
-
 	cc := NewCachingClient(c)
-	cc.cache.cache.Put("key", "value")
-	cc.cache.infcache.Put("key", "value")
+	warmSubjectsCache(t, c, cc)
 
-	resultVersion, err := cc.DeleteSchemaByVersion(ctx, subject, version, true)
+	c.EXPECT().DeleteSchemaByVersion(ctx, "s1", 2, true).Return(2, nil)
+
+	resultVersion, err := cc.DeleteSchemaByVersion(ctx, "s1", 2, true)
 	require.NoError(t, err)
-	require.EqualValues(t, version, resultVersion)
+	require.EqualValues(t, 2, resultVersion)
+
+	requireCached(t, cc, false, "subjects", "versions/s1", "version/s1/latest", "version/s1/2")
+	requireCached(t, cc, false, "id/2", schemaValueKey("s1", "b"))
+
+	requireCached(t, cc, true, "version/s1/1", "versions/s2", "version/s2/latest", "version/s2/1")
+	requireCached(t, cc, true, "id/1", schemaValueKey("s1", "a"), schemaValueKey("s2", "a"))
+
+	// schema id used by other subject is kept
+	c.EXPECT().DeleteSchemaByVersion(ctx, "s1", 1, true).Return(1, nil)
 
-	_, exists := cc.cache.cache.GetIfPresent("key")
-	require.False(t, exists)
+	_, err = cc.DeleteSchemaByVersion(ctx, "s1", 1, true)
+	require.NoError(t, err)
 
-	_, exists = cc.cache.infcache.GetIfPresent("key")
-	require.False(t, exists)
+	requireCached(t, cc, false, "version/s1/1")
+	requireCached(t, cc, false, schemaValueKey("s1", "a"))
+	requireCached(t, cc, true, "id/1", schemaValueKey("s2", "a"))
 }
 
 func TestCachingClientIsSchemaCompatible(t *testing.T) {
@@ -303,3 +408,1106 @@ func TestCachingClientIsSchemaCompatible(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, ok)
 }
+
+func TestCachingClientCheckSchemaCompatibility(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	schema := makeSchema()
+	result := &CompatibilityResult{IsCompatible: true}
+	c.EXPECT().CheckSchemaCompatibility(ctx, schema, true).Times(2).Return(result, nil)
+
+	cc := NewCachingClient(c)
+
+	// compatibility checks are not cached
+	for i := 0; i < 2; i++ {
+		r, err := cc.CheckSchemaCompatibility(ctx, schema, true)
+		require.NoError(t, err)
+		require.Equal(t, result, r)
+	}
+}
+
+func TestCachingClientGetGlobalCompatibilityLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	c.EXPECT().GetGlobalCompatibilityLevel(ctx).Times(1).Return(BackwardCompatibility, nil)
+
+	cc := NewCachingClient(c)
+
+	level, err := cc.GetGlobalCompatibilityLevel(ctx)
+	require.NoError(t, err)
+	require.Equal(t, BackwardCompatibility, level)
+
+	level, err = cc.GetGlobalCompatibilityLevel(ctx)
+	require.NoError(t, err)
+	require.Equal(t, BackwardCompatibility, level)
+
+	// setting global compatibility level invalidates cache
+	c.EXPECT().SetGlobalCompatibilityLevel(ctx, FullCompatibility).Return(nil)
+	c.EXPECT().GetGlobalCompatibilityLevel(ctx).Times(1).Return(FullCompatibility, nil)
+
+	err = cc.SetGlobalCompatibilityLevel(ctx, FullCompatibility)
+	require.NoError(t, err)
+
+	level, err = cc.GetGlobalCompatibilityLevel(ctx)
+	require.NoError(t, err)
+	require.Equal(t, FullCompatibility, level)
+}
+
+func TestCachingClientGetCompatibilityLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	subject := "subject"
+	c.EXPECT().GetCompatibilityLevel(ctx, subject, false).Times(1).Return(FullTransitiveCompatibility, nil)
+
+	cc := NewCachingClient(c)
+
+	level, err := cc.GetCompatibilityLevel(ctx, subject, false)
+	require.NoError(t, err)
+	require.Equal(t, FullTransitiveCompatibility, level)
+
+	level, err = cc.GetCompatibilityLevel(ctx, subject, true)
+	require.NoError(t, err)
+	require.Equal(t, FullTransitiveCompatibility, level)
+
+	// deleting subject compatibility level invalidates cache
+	c.EXPECT().DeleteCompatibilityLevel(ctx, subject).Return(FullTransitiveCompatibility, nil)
+	c.EXPECT().GetCompatibilityLevel(ctx, subject, false).Times(1).Return(CompatibilityLevel(""), fmt.Errorf("%w: not configured", ErrNotFound))
+
+	_, err = cc.DeleteCompatibilityLevel(ctx, subject)
+	require.NoError(t, err)
+
+	_, err = cc.GetCompatibilityLevel(ctx, subject, false)
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	// subjects without compatibility level default to global one
+	c.EXPECT().GetGlobalCompatibilityLevel(ctx).Times(1).Return(BackwardCompatibility, nil)
+
+	level, err = cc.GetCompatibilityLevel(ctx, subject, true)
+	require.NoError(t, err)
+	require.Equal(t, BackwardCompatibility, level)
+
+	// levels loaded right after invalidation stay cached
+	c.EXPECT().SetCompatibilityLevel(ctx, subject, FullCompatibility).Return(nil)
+	c.EXPECT().GetCompatibilityLevel(ctx, subject, false).Times(1).Return(FullCompatibility, nil)
+	c.EXPECT().SetGlobalCompatibilityLevel(ctx, NoneCompatibility).Return(nil)
+	c.EXPECT().GetGlobalCompatibilityLevel(ctx).Times(1).Return(NoneCompatibility, nil)
+
+	require.NoError(t, cc.SetCompatibilityLevel(ctx, subject, FullCompatibility))
+	require.NoError(t, cc.SetGlobalCompatibilityLevel(ctx, NoneCompatibility))
+
+	for i := 0; i < 2; i++ {
+		level, err = cc.GetCompatibilityLevel(ctx, subject, false)
+		require.NoError(t, err)
+		require.Equal(t, FullCompatibility, level)
+
+		level, err = cc.GetGlobalCompatibilityLevel(ctx)
+		require.NoError(t, err)
+		require.Equal(t, NoneCompatibility, level)
+	}
+}
+
+func TestCachingClientLookupSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	schema := makeSchema(withTestReferences)
+	foundSchema := *schema
+	foundSchema.ID = 1
+	foundSchema.Version = 1
+	c.EXPECT().LookupSchema(ctx, schema).Times(1).Return(&foundSchema, nil)
+
+	cc := NewCachingClient(c)
+
+	result, err := cc.LookupSchema(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, &foundSchema, result)
+
+	checkSchemaCache(t, cc, result)
+
+	// schema is looked up from cache and shared with create schema
+	result, err = cc.LookupSchema(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, &foundSchema, result)
+
+	result, err = cc.CreateSchema(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, &foundSchema, result)
+}
+
+func TestCachingClientNegativeCaching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	cc := NewCachingClient(c, WithNegativeCaching(NegativeCacheTTL{
+		SchemaByID:   time.Minute,
+		LatestSchema: time.Hour,
+	}))
+
+	now := time.Now()
+	cc.negative.now = func() time.Time { return now }
+
+	notFound := &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: errCodeSchemaNotFound}
+	subjectNotFound := &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: errCodeSubjectNotFound}
+
+	// not found errors are cached until ttl expires
+	c.EXPECT().GetSchemaByID(ctx, 1).Times(1).Return(nil, notFound)
+	c.EXPECT().GetLatestSchema(ctx, "subject").Times(1).Return(nil, subjectNotFound)
+
+	for i := 0; i < 2; i++ {
+		_, err := cc.GetSchemaByID(ctx, 1)
+		require.True(t, errors.Is(err, ErrSchemaNotFound))
+
+		_, err = cc.GetLatestSchema(ctx, "subject")
+		require.True(t, errors.Is(err, ErrSubjectNotFound))
+	}
+
+	now = now.Add(time.Minute)
+
+	c.EXPECT().GetSchemaByID(ctx, 1).Times(1).Return(nil, notFound)
+
+	_, err := cc.GetSchemaByID(ctx, 1)
+	require.True(t, errors.Is(err, ErrSchemaNotFound))
+
+	// not found errors are not cached for kinds without ttl
+	c.EXPECT().GetSchemaByVersion(ctx, "subject", 1).Times(2).Return(nil, subjectNotFound)
+
+	for i := 0; i < 2; i++ {
+		_, err := cc.GetSchemaByVersion(ctx, "subject", 1)
+		require.True(t, errors.Is(err, ErrSubjectNotFound))
+	}
+
+	// other errors are not cached
+	c.EXPECT().GetSchemaByID(ctx, 2).Times(2).Return(nil, ErrServerError)
+
+	for i := 0; i < 2; i++ {
+		_, err := cc.GetSchemaByID(ctx, 2)
+		require.True(t, errors.Is(err, ErrServerError))
+	}
+
+	// creating schema removes not found errors of subject and schema id
+	schema := &Schema{Subject: "subject", Schema: "schema"}
+	created := &Schema{Subject: "subject", Schema: "schema", ID: 1, Version: 1}
+
+	c.EXPECT().CreateSchema(ctx, schema).Return(created, nil)
+
+	_, err = cc.CreateSchema(ctx, schema)
+	require.NoError(t, err)
+
+	result, err := cc.GetSchemaByID(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, created, result)
+
+	c.EXPECT().GetLatestSchema(ctx, "subject").Times(1).Return(created, nil)
+
+	result, err = cc.GetLatestSchema(ctx, "subject")
+	require.NoError(t, err)
+	require.Equal(t, created, result)
+}
+
+func TestCachingClientTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	cc := NewCachingClient(c, WithExpiration(time.Hour), WithTTL(CacheTTL{
+		Subjects:   time.Minute,
+		SchemaByID: 2 * time.Minute,
+	}))
+
+	now := time.Now()
+	cc.cache.now = func() time.Time { return now }
+
+	schema := &Schema{Subject: "subject", Schema: "schema", ID: 1, Version: 1}
+
+	// read reads subjects, schema by id and latest schema, expecting upstream
+	// calls for expired entries
+	read := func(subjects, byID, latest bool) {
+		if subjects {
+			c.EXPECT().GetSubjects(ctx).Return([]string{"subject"}, nil)
+		}
+
+		if byID {
+			c.EXPECT().GetSchemaByID(ctx, 1).Return(schema, nil)
+		}
+
+		if latest {
+			c.EXPECT().GetLatestSchema(ctx, "subject").Return(schema, nil)
+		}
+
+		_, err := cc.GetSubjects(ctx)
+		require.NoError(t, err)
+
+		_, err = cc.GetSchemaByID(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = cc.GetLatestSchema(ctx, "subject")
+		require.NoError(t, err)
+	}
+
+	read(true, true, true)
+
+	// subjects expire after their ttl
+	now = now.Add(time.Minute)
+	read(true, false, false)
+
+	// schemas by id expire after their ttl
+	now = now.Add(time.Minute)
+	read(true, true, false)
+
+	// latest schemas expire with other mutable entries
+	now = now.Add(time.Hour)
+	read(true, true, true)
+}
+
+func TestCachingClientCacheLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	schema := func(id int) *Schema {
+		return &Schema{Schema: fmt.Sprintf("schema %d", id), ID: id}
+	}
+
+	cc := NewCachingClient(c,
+		WithSchemaValueCaching(false),
+		WithEvictionPolicy(TinyLFUEviction),
+		WithCacheLimits(CacheLimit{MaxEntries: 100}, CacheLimit{
+			MaxBytes: 2*approxSize(fmt.Sprintf(cacheKeySchemaByID, 1)) + 2*approxSize(&goburrowEntry{value: &cacheEntry{value: schema(1)}}),
+		}),
+	)
+
+	for id := 1; id <= 3; id++ {
+		c.EXPECT().GetSchemaByID(ctx, id).Times(1).Return(schema(id), nil)
+
+		_, err := cc.GetSchemaByID(ctx, id)
+		require.NoError(t, err)
+	}
+
+	// least recently used schema is evicted
+	c.EXPECT().GetSchemaByID(ctx, 1).Times(1).Return(schema(1), nil)
+
+	for _, id := range []int{3, 2, 1} {
+		result, err := cc.GetSchemaByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, schema(id), result)
+	}
+
+	// evicted entries are removed asynchronously
+	require.Eventually(t, func() bool {
+		stats := cc.Stats()[SchemaByIDCacheKind]
+		return stats.Evictions == 2 && stats.Entries == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, []string{"id/1", "id/2"}, cc.CacheKeys()[SchemaByIDCacheKind])
+
+	require.Panics(t, func() { NewCachingClient(c, WithEvictionPolicy("fifo")) })
+}
+
+func TestCachingClientStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	cc := NewCachingClient(c)
+
+	schema := &Schema{Subject: "subject", Version: 1, ID: 1, Schema: "schema"}
+
+	c.EXPECT().GetLatestSchema(ctx, "subject").Return(schema, nil)
+	c.EXPECT().GetSubjects(ctx).Return(nil, errors.New("err"))
+	c.EXPECT().GetCompatibilityLevel(ctx, "subject", false).Return(CompatibilityLevel(""), ErrNotFound)
+	c.EXPECT().GetGlobalCompatibilityLevel(ctx).Return(BackwardCompatibility, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := cc.GetLatestSchema(ctx, "subject")
+		require.NoError(t, err)
+	}
+
+	_, err := cc.GetSchemaByID(ctx, 1)
+	require.NoError(t, err)
+
+	_, err = cc.GetSubjects(ctx)
+	require.Error(t, err)
+
+	_, err = cc.GetCompatibilityLevel(ctx, "subject", true)
+	require.NoError(t, err)
+
+	require.Equal(t, map[CacheKind]CacheStats{
+		SubjectsCacheKind:        {Misses: 1, Loads: 1, LoadErrors: 1},
+		SubjectVersionsCacheKind: {},
+		LatestSchemaCacheKind:    {Hits: 1, Misses: 1, Loads: 1, Entries: 1},
+		SchemaByVersionCacheKind: {Entries: 1},
+		SchemaByIDCacheKind:      {Hits: 1, Entries: 1},
+		SchemaValueCacheKind:     {Entries: 1},
+
+		// subject level is cached as not configured and global level is
+		// loaded
+		CompatibilityLevelCacheKind: {Misses: 2, Loads: 2, Entries: 2},
+	}, cc.Stats())
+
+	require.Equal(t, map[CacheKind][]string{
+		LatestSchemaCacheKind:       {"version/subject/latest"},
+		SchemaByVersionCacheKind:    {"version/subject/1"},
+		SchemaByIDCacheKind:         {"id/1"},
+		SchemaValueCacheKind:        {schemaValueKey("subject", "schema")},
+		CompatibilityLevelCacheKind: {"config", "config/subject"},
+	}, cc.CacheKeys())
+
+	// statistics of backends, that can not be inspected, only include reads
+	// and loads
+	cc = NewCachingClient(c, WithCacheBackend(struct{ CacheBackend }{NewMapCacheBackend()}))
+
+	c.EXPECT().GetLatestSchema(ctx, "subject").Return(schema, nil)
+
+	_, err = cc.GetLatestSchema(ctx, "subject")
+	require.NoError(t, err)
+
+	require.Equal(t, CacheStats{Misses: 1, Loads: 1}, cc.Stats()[LatestSchemaCacheKind])
+	require.Empty(t, cc.CacheKeys())
+}
+
+func TestCachingClientServeStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	cc := NewCachingClient(c, WithServeStale(time.Minute), WithTTL(CacheTTL{
+		Subjects:     time.Minute,
+		LatestSchema: time.Minute,
+	}))
+
+	now := time.Now()
+	cc.cache.now = func() time.Time { return now }
+
+	schema := &Schema{Subject: "subject", Schema: "schema", ID: 1, Version: 1}
+	unavailable := &RegistryError{StatusCode: http.StatusServiceUnavailable}
+
+	c.EXPECT().GetLatestSchema(ctx, "subject").Return(schema, nil)
+	c.EXPECT().GetSubjects(ctx).Return([]string{"subject"}, nil)
+
+	_, err := cc.GetLatestSchema(ctx, "subject")
+	require.NoError(t, err)
+
+	_, err = cc.GetSubjects(ctx)
+	require.NoError(t, err)
+
+	// expired values are returned with stale error, if registry is unavailable
+	now = now.Add(90 * time.Second)
+
+	// expired values are loaded in background, not bound to callers context
+	c.EXPECT().GetLatestSchema(gomock.Any(), "subject").Return(nil, unavailable)
+	c.EXPECT().GetSubjects(gomock.Any()).Return(nil, unavailable)
+
+	result, err := cc.GetLatestSchema(ctx, "subject")
+	require.True(t, errors.Is(err, ErrStale))
+	require.True(t, errors.Is(err, ErrServerError))
+	require.Equal(t, schema, result)
+
+	var staleErr *StaleError
+	require.True(t, errors.As(err, &staleErr))
+	require.Equal(t, 30*time.Second, staleErr.Age)
+
+	subjects, err := cc.GetSubjects(ctx)
+	require.True(t, errors.Is(err, ErrStale))
+	require.Equal(t, []string{"subject"}, subjects)
+
+	// expired values are not returned, if registry responds with client error
+	c.EXPECT().GetLatestSchema(gomock.Any(), "subject").Return(nil, &RegistryError{StatusCode: http.StatusNotFound})
+
+	result, err = cc.GetLatestSchema(ctx, "subject")
+	require.True(t, errors.Is(err, ErrNotFound))
+	require.False(t, errors.Is(err, ErrStale))
+	require.Nil(t, result)
+
+	// values are not returned after maximum staleness
+	now = now.Add(30 * time.Second)
+
+	c.EXPECT().GetLatestSchema(ctx, "subject").Return(nil, unavailable)
+
+	result, err = cc.GetLatestSchema(ctx, "subject")
+	require.True(t, errors.Is(err, ErrServerError))
+	require.False(t, errors.Is(err, ErrStale))
+	require.Nil(t, result)
+}
+
+func TestCachingClientBackgroundRefresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	cc := NewCachingClient(c, WithBackgroundRefresh(10*time.Second), WithTTL(CacheTTL{Subjects: time.Minute}))
+
+	var mu sync.Mutex
+	now := time.Now()
+	cc.cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	c.EXPECT().GetSubjects(ctx).Return([]string{"s1"}, nil)
+
+	subjects, err := cc.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"s1"}, subjects)
+
+	// entries are refreshed in background once, when read before expiration
+	advance(55 * time.Second)
+
+	release := make(chan struct{})
+	refreshed := make(chan struct{})
+	c.EXPECT().GetSubjects(gomock.Any()).Times(1).DoAndReturn(func(context.Context) ([]string, error) {
+		<-release
+		defer close(refreshed)
+		return []string{"s1", "s2"}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		subjects, err = cc.GetSubjects(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"s1"}, subjects)
+	}
+
+	close(release)
+	<-refreshed
+
+	require.Eventually(t, func() bool {
+		subjects, err := cc.GetSubjects(ctx)
+		return err == nil && reflect.DeepEqual([]string{"s1", "s2"}, subjects)
+	}, time.Second, time.Millisecond)
+
+	// refreshed entries are not refreshed again before their refresh time
+	advance(40 * time.Second)
+
+	subjects, err = cc.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"s1", "s2"}, subjects)
+}
+
+func TestCachingClientServeStaleUnresponsive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	cc := NewCachingClient(c,
+		WithServeStale(time.Minute),
+		WithTTL(CacheTTL{Subjects: time.Minute}),
+		WithRefreshTimeout(100*time.Millisecond),
+		WithStaleWait(10*time.Millisecond))
+
+	var mu sync.Mutex
+	now := time.Now()
+	cc.cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	c.EXPECT().GetSubjects(ctx).Return([]string{"s1"}, nil)
+
+	_, err := cc.GetSubjects(ctx)
+	require.NoError(t, err)
+
+	mu.Lock()
+	now = now.Add(90 * time.Second)
+	mu.Unlock()
+
+	// expired values are returned without error after stale wait, while
+	// schema registry is slow
+	release := make(chan struct{})
+	c.EXPECT().GetSubjects(gomock.Any()).Times(1).DoAndReturn(func(context.Context) ([]string, error) {
+		<-release
+		return []string{"s1", "s2"}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		subjects, err := cc.GetSubjects(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"s1"}, subjects)
+	}
+
+	close(release)
+
+	require.Eventually(t, func() bool {
+		subjects, err := cc.GetSubjects(ctx)
+		return err == nil && reflect.DeepEqual([]string{"s1", "s2"}, subjects)
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	now = now.Add(90 * time.Second)
+	mu.Unlock()
+
+	// hanging loads are canceled after refresh timeout, after which expired
+	// values are returned with stale error
+	canceled := make(chan struct{}, 2)
+	c.EXPECT().GetSubjects(gomock.Any()).Times(2).DoAndReturn(func(ctx context.Context) ([]string, error) {
+		<-ctx.Done()
+		canceled <- struct{}{}
+		return nil, ctx.Err()
+	})
+
+	subjects, err := cc.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"s1", "s2"}, subjects)
+
+	<-canceled
+
+	require.Eventually(t, func() bool {
+		subjects, err := cc.GetSubjects(ctx)
+		return errors.Is(err, ErrStale) && errors.Is(err, context.DeadlineExceeded) && reflect.DeepEqual([]string{"s1", "s2"}, subjects)
+	}, time.Second, time.Millisecond)
+
+	<-canceled
+}
+
+func TestCachingClientPersistentCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s1 := &Schema{Subject: "s1", Version: 1, ID: 1, Schema: "a"}
+	s2 := &Schema{Subject: "s2", Version: 1, ID: 2, Schema: "b"}
+
+	c := NewMockClient(ctrl)
+	c.EXPECT().GetSchemaByID(ctx, 1).Return(s1, nil)
+	c.EXPECT().GetSchemaByVersion(ctx, "s2", 1).Return(s2, nil)
+
+	cc := NewCachingClient(c, WithPersistentCache(dir, 0))
+
+	_, err := cc.GetSchemaByID(ctx, 1)
+	require.NoError(t, err)
+
+	_, err = cc.GetSchemaByVersion(ctx, "s2", 1)
+	require.NoError(t, err)
+
+	// persisted schemas are loaded by new client
+	c = NewMockClient(ctrl)
+	cc = NewCachingClient(c, WithPersistentCache(dir, 0))
+
+	for _, schema := range []*Schema{s1, s2} {
+		result, err := cc.GetSchemaByID(ctx, schema.ID)
+		require.NoError(t, err)
+		require.Equal(t, schema, result)
+
+		result, err = cc.GetSchemaByVersion(ctx, schema.Subject, schema.Version)
+		require.NoError(t, err)
+		require.Equal(t, schema, result)
+	}
+
+	// persisted schemas are not cached by value, as subjects might have been
+	// deleted since, so creating schemas reaches schema registry
+	c.EXPECT().CreateSchema(ctx, s1).Times(1).Return(s1, nil)
+	c.EXPECT().LookupSchema(ctx, s2).Times(1).Return(s2, nil)
+
+	_, err = cc.CreateSchema(ctx, s1)
+	require.NoError(t, err)
+
+	_, err = cc.LookupSchema(ctx, s2)
+	require.NoError(t, err)
+
+	// deleted schemas are removed from persistent cache
+	c.EXPECT().DeleteSubject(ctx, "s2", true).Return([]int{1}, nil)
+
+	_, err = cc.DeleteSubject(ctx, "s2", true)
+	require.NoError(t, err)
+
+	c = NewMockClient(ctrl)
+	cc = NewCachingClient(c, WithPersistentCache(dir, 0))
+
+	c.EXPECT().GetSchemaByID(ctx, 2).Return(nil, ErrNotFound)
+
+	_, err = cc.GetSchemaByID(ctx, 2)
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	result, err := cc.GetSchemaByID(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, s1, result)
+
+	// schemas are cached in memory, if persistent cache cannot be opened
+	c = NewMockClient(ctrl)
+	cc = NewCachingClient(c, WithPersistentCache(filepath.Join(dir, "id-1.json"), 0))
+	require.Nil(t, cc.cache.disk)
+
+	c.EXPECT().GetSchemaByID(ctx, 1).Times(1).Return(s1, nil)
+
+	for i := 0; i < 2; i++ {
+		result, err = cc.GetSchemaByID(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, s1, result)
+	}
+}
+
+// recordingCacheBackend records kinds of cached keys
+type recordingCacheBackend struct {
+	*MapCacheBackend
+
+	mu    sync.Mutex
+	kinds map[string]CacheKind
+}
+
+func (b *recordingCacheBackend) Put(kind CacheKind, key string, val interface{}) {
+	b.mu.Lock()
+	b.kinds[key] = kind
+	b.mu.Unlock()
+
+	b.MapCacheBackend.Put(kind, key, val)
+}
+
+func TestCachingClientCacheBackend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	backend := &recordingCacheBackend{MapCacheBackend: NewMapCacheBackend(), kinds: map[string]CacheKind{}}
+	cc := NewCachingClient(c, WithCacheBackend(backend))
+
+	schema := &Schema{Subject: "subject", Version: 1, ID: 1, Schema: "schema"}
+
+	c.EXPECT().GetSubjects(ctx).Return([]string{"subject"}, nil)
+	c.EXPECT().GetSubjectVersions(ctx, "subject").Return([]int{1}, nil)
+	c.EXPECT().GetLatestSchema(ctx, "subject").Return(schema, nil)
+	c.EXPECT().GetCompatibilityLevel(ctx, "subject", false).Return(BackwardCompatibility, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := cc.GetSubjects(ctx)
+		require.NoError(t, err)
+
+		_, err = cc.GetSubjectVersions(ctx, "subject")
+		require.NoError(t, err)
+
+		_, err = cc.GetLatestSchema(ctx, "subject")
+		require.NoError(t, err)
+
+		_, err = cc.GetSchemaByID(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = cc.GetCompatibilityLevel(ctx, "subject", false)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, map[string]CacheKind{
+		"subjects":                          SubjectsCacheKind,
+		"versions/subject":                  SubjectVersionsCacheKind,
+		"version/subject/latest":            LatestSchemaCacheKind,
+		"version/subject/1":                 SchemaByVersionCacheKind,
+		"id/1":                              SchemaByIDCacheKind,
+		schemaValueKey("subject", "schema"): SchemaValueCacheKind,
+		"config/subject":                    CompatibilityLevelCacheKind,
+	}, backend.kinds)
+
+	// deleted entries are invalidated in backend
+	c.EXPECT().DeleteSubject(ctx, "subject", true).Return([]int{1}, nil)
+
+	_, err := cc.DeleteSubject(ctx, "subject", true)
+	require.NoError(t, err)
+	require.Equal(t, 0, backend.Len())
+}
+
+func TestCachingClientCoalescesReads(t *testing.T) {
+	ctx := context.Background()
+	schema := makeSchema(withRandomID, withRandomVersion)
+
+	tests := []struct {
+		name   string
+		expect func(c *MockClient) *gomock.Call
+		call   func(cc *CachingClient) (interface{}, error)
+	}{
+		{
+			name:   "GetSubjects",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetSubjects(ctx) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetSubjects(ctx) },
+		},
+		{
+			name:   "GetSubjectVersions",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetSubjectVersions(ctx, "subject1") },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetSubjectVersions(ctx, "subject1") },
+		},
+		{
+			name:   "GetSchemaByID",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetSchemaByID(ctx, 1) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetSchemaByID(ctx, 1) },
+		},
+		{
+			name:   "GetSchemaByVersion",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetSchemaByVersion(ctx, "subject1", 1) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetSchemaByVersion(ctx, "subject1", 1) },
+		},
+		{
+			name:   "GetLatestSchema",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetLatestSchema(ctx, "subject1") },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetLatestSchema(ctx, "subject1") },
+		},
+		{
+			name:   "LookupSchema",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().LookupSchema(ctx, schema) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.LookupSchema(ctx, schema) },
+		},
+		{
+			name:   "GetSchemaSubjectVersions",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetSchemaSubjectVersions(ctx, 1) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetSchemaSubjectVersions(ctx, 1) },
+		},
+		{
+			name:   "GetAllSubjectVersions",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetAllSubjectVersions(ctx, "subject1") },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetAllSubjectVersions(ctx, "subject1") },
+		},
+		{
+			name:   "GetSchemaVersions",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetSchemaVersions(ctx, 1) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetSchemaVersions(ctx, 1) },
+		},
+		{
+			name:   "GetReferencedBy",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetReferencedBy(ctx, "subject1", 1) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetReferencedBy(ctx, "subject1", 1) },
+		},
+		{
+			name:   "GetGlobalCompatibilityLevel",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetGlobalCompatibilityLevel(ctx) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetGlobalCompatibilityLevel(ctx) },
+		},
+		{
+			name:   "GetCompatibilityLevel",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetCompatibilityLevel(ctx, "subject1", false) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetCompatibilityLevel(ctx, "subject1", false) },
+		},
+		{
+			name:   "GetGlobalMode",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetGlobalMode(ctx) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetGlobalMode(ctx) },
+		},
+		{
+			name:   "GetMode",
+			expect: func(c *MockClient) *gomock.Call { return c.EXPECT().GetMode(ctx, "subject1", true) },
+			call:   func(cc *CachingClient) (interface{}, error) { return cc.GetMode(ctx, "subject1", true) },
+		},
+	}
+
+	results := map[string]interface{}{
+		"GetSubjects":                 []string{"subject1"},
+		"GetSubjectVersions":          []int{1},
+		"GetAllSubjectVersions":       []int{1, 2},
+		"GetSchemaSubjectVersions":    map[string]int{"subject1": 1},
+		"GetSchemaVersions":           []SubjectVersion{{"subject1", 1}, {"subject1", 2}},
+		"GetReferencedBy":             []int{2},
+		"GetGlobalCompatibilityLevel": FullCompatibility,
+		"GetCompatibilityLevel":       FullCompatibility,
+		"GetGlobalMode":               ReadWriteMode,
+		"GetMode":                     ReadWriteMode,
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := NewMockClient(ctrl)
+			cc := NewCachingClient(c)
+
+			result, ok := results[test.name]
+			if !ok {
+				result = schema
+			}
+
+			release := make(chan struct{})
+			test.expect(c).Times(1).DoAndReturn(blockingReturn(test.name, release, result))
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					val, err := test.call(cc)
+					require.NoError(t, err)
+					require.Equal(t, result, val)
+				}()
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+		})
+	}
+}
+
+// blockingReturn creates mock function for client method, which blocks until
+// release is closed and returns result
+func blockingReturn(method string, release chan struct{}, result interface{}) interface{} {
+	fnType := reflect.ValueOf((*Client)(nil)).Type().Elem()
+	m, _ := fnType.MethodByName(method)
+
+	return reflect.MakeFunc(m.Type, func(args []reflect.Value) []reflect.Value {
+		<-release
+
+		return []reflect.Value{
+			reflect.ValueOf(result).Convert(m.Type.Out(0)),
+			reflect.Zero(m.Type.Out(1)),
+		}
+	}).Interface()
+}
+
+func TestCachingClientCoalescedError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewMockClient(ctrl)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	expectedErr := errors.New("error")
+	c.EXPECT().GetSchemaByID(ctx, 1).Times(1).DoAndReturn(func(ctx context.Context, id int) (*Schema, error) {
+		<-release
+		return nil, expectedErr
+	})
+
+	cc := NewCachingClient(c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			schema, err := cc.GetSchemaByID(ctx, 1)
+			require.Equal(t, expectedErr, err)
+			require.Nil(t, schema)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// errors are not cached
+	c.EXPECT().GetSchemaByID(ctx, 1).Return(makeSchema(), nil)
+	_, err := cc.GetSchemaByID(ctx, 1)
+	require.NoError(t, err)
+}
+
+// newRegistryMock creates mock client, which delegates calls to client of
+// fake schema registry, and returns it together with uncached client
+func newRegistryMock(t *testing.T, ctrl *gomock.Controller) (*MockClient, *BaseClient) {
+	server := srtest.NewServer()
+	t.Cleanup(server.Close)
+
+	base := NewBaseClient(WithURL(server.URL))
+
+	c := NewMockClient(ctrl)
+	c.EXPECT().GetSubjects(gomock.Any()).AnyTimes().DoAndReturn(base.GetSubjects)
+	c.EXPECT().GetSubjectVersions(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.GetSubjectVersions)
+	c.EXPECT().GetSchemaByID(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.GetSchemaByID)
+	c.EXPECT().GetSchemaByVersion(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.GetSchemaByVersion)
+	c.EXPECT().GetLatestSchema(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.GetLatestSchema)
+	c.EXPECT().CreateSchema(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.CreateSchema)
+	c.EXPECT().LookupSchema(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.LookupSchema)
+	c.EXPECT().DeleteSubject(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.DeleteSubject)
+	c.EXPECT().DeleteSchemaByVersion(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(base.DeleteSchemaByVersion)
+
+	return c, base
+}
+
+type cacheTestStep struct {
+	op      string
+	subject string
+	schema  string
+	version int
+}
+
+func createStep(subject string, schema string) cacheTestStep {
+	return cacheTestStep{op: "create", subject: subject, schema: schema}
+}
+
+func deleteVersionStep(subject string, version int) cacheTestStep {
+	return cacheTestStep{op: "deleteVersion", subject: subject, version: version}
+}
+
+func deleteSubjectStep(subject string) cacheTestStep {
+	return cacheTestStep{op: "deleteSubject", subject: subject}
+}
+
+func deletePermanentStep(subject string) cacheTestStep {
+	return cacheTestStep{op: "deletePermanent", subject: subject}
+}
+
+var readStep = cacheTestStep{op: "read"}
+
+// checkCachedReads checks that reads through caching client return the same
+// results as reads made directly against registry
+func checkCachedReads(t *testing.T, cc *CachingClient, base *BaseClient, step int) {
+	ctx := context.Background()
+
+	requireSameResult := func(name string, cached, expected interface{}, cachedErr, expectedErr error) {
+		msg := fmt.Sprintf("step %d: %s", step, name)
+		require.Equal(t, expectedErr != nil, cachedErr != nil, msg)
+
+		if expectedErr == nil {
+			require.Equal(t, expected, cached, msg)
+		}
+	}
+
+	subjects, err := cc.GetSubjects(ctx)
+	expectedSubjects, expectedErr := base.GetSubjects(ctx)
+	require.Equal(t, expectedErr, err)
+	require.ElementsMatch(t, expectedSubjects, subjects, "step %d: subjects", step)
+
+	for _, subject := range []string{"s1", "s2"} {
+		versions, err := cc.GetSubjectVersions(ctx, subject)
+		expectedVersions, expectedErr := base.GetSubjectVersions(ctx, subject)
+		requireSameResult("versions "+subject, versions, expectedVersions, err, expectedErr)
+
+		latest, err := cc.GetLatestSchema(ctx, subject)
+		expectedLatest, expectedErr := base.GetLatestSchema(ctx, subject)
+		requireSameResult("latest "+subject, latest, expectedLatest, err, expectedErr)
+
+		for version := 1; version <= 4; version++ {
+			schema, err := cc.GetSchemaByVersion(ctx, subject, version)
+			expectedSchema, expectedErr := base.GetSchemaByVersion(ctx, subject, version)
+			requireSameResult(fmt.Sprintf("version %s/%d", subject, version), schema, expectedSchema, err, expectedErr)
+		}
+	}
+}
+
+func TestCachingClientCoherentWrites(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []cacheTestStep
+	}{
+		{
+			name: "create new versions",
+			steps: []cacheTestStep{
+				readStep,
+				createStep("s1", "a"), readStep,
+				createStep("s1", "b"), readStep,
+				createStep("s1", "c"), readStep,
+			},
+		},
+		{
+			name: "create existing version",
+			steps: []cacheTestStep{
+				createStep("s1", "a"), createStep("s1", "b"), readStep,
+				createStep("s1", "a"), readStep,
+				createStep("s1", "b"), readStep,
+			},
+		},
+		{
+			name: "create after soft deleting version",
+			steps: []cacheTestStep{
+				createStep("s1", "a"), createStep("s1", "b"), readStep,
+				deleteVersionStep("s1", 2), readStep,
+				createStep("s1", "b"), readStep,
+				deleteVersionStep("s1", 1), readStep,
+				createStep("s1", "a"), readStep,
+			},
+		},
+		{
+			name: "create after soft deleting subject",
+			steps: []cacheTestStep{
+				createStep("s1", "a"), createStep("s2", "a"), readStep,
+				deleteSubjectStep("s1"), readStep,
+				createStep("s1", "a"), readStep,
+				createStep("s2", "b"), readStep,
+			},
+		},
+		{
+			name: "create after permanently deleting subject",
+			steps: []cacheTestStep{
+				createStep("s1", "a"), createStep("s1", "b"), readStep,
+				deletePermanentStep("s1"), readStep,
+				createStep("s1", "b"), readStep,
+			},
+		},
+		{
+			name: "create new subjects",
+			steps: []cacheTestStep{
+				readStep,
+				createStep("s1", "a"), readStep,
+				createStep("s2", "b"), readStep,
+				deleteSubjectStep("s1"), readStep,
+				createStep("s2", "a"), readStep,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c, base := newRegistryMock(t, ctrl)
+			cc := NewCachingClient(c)
+			ctx := context.Background()
+
+			for i, step := range test.steps {
+				var err error
+
+				switch step.op {
+				case "create":
+					schema := &Schema{Subject: step.subject, Type: ProtobufSchemaType, Schema: step.schema}
+
+					var created *Schema
+					created, err = cc.CreateSchema(ctx, schema)
+					if err == nil {
+						latest, latestErr := base.GetLatestSchema(ctx, step.subject)
+						require.NoError(t, latestErr)
+
+						found, lookupErr := base.LookupSchema(ctx, schema)
+						require.NoError(t, lookupErr)
+						require.Equal(t, found.ID, created.ID)
+						require.Equal(t, found.Version, created.Version)
+						require.LessOrEqual(t, created.Version, latest.Version)
+					}
+				case "deleteVersion":
+					_, err = cc.DeleteSchemaByVersion(ctx, step.subject, step.version, false)
+				case "deleteSubject":
+					_, err = cc.DeleteSubject(ctx, step.subject, false)
+				case "deletePermanent":
+					_, err = cc.DeleteSubject(ctx, step.subject, true)
+				case "read":
+					checkCachedReads(t, cc, base, i)
+				}
+
+				require.NoError(t, err, "step %d: %s", i, step.op)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package srclient
 
 import (
 	"context"
+	"fmt"
 	"regexp"
 )
 
@@ -25,6 +26,60 @@ const (
 	JSONSchemaType SchemaType = "JSON"
 )
 
+// CompatibilityLevel defines schema registry compatibility level
+type CompatibilityLevel string
+
+func (l CompatibilityLevel) String() string {
+	return string(l)
+}
+
+const (
+	// BackwardCompatibility level allows consumers using new schema to read data
+	// produced with last registered schema
+	BackwardCompatibility CompatibilityLevel = "BACKWARD"
+
+	// BackwardTransitiveCompatibility level allows consumers using new schema to
+	// read data produced with all previously registered schemas
+	BackwardTransitiveCompatibility CompatibilityLevel = "BACKWARD_TRANSITIVE"
+
+	// ForwardCompatibility level allows consumers using last registered schema
+	// to read data produced with new schema
+	ForwardCompatibility CompatibilityLevel = "FORWARD"
+
+	// ForwardTransitiveCompatibility level allows consumers using all previously
+	// registered schemas to read data produced with new schema
+	ForwardTransitiveCompatibility CompatibilityLevel = "FORWARD_TRANSITIVE"
+
+	// FullCompatibility level requires both backward and forward compatibility
+	// with last registered schema
+	FullCompatibility CompatibilityLevel = "FULL"
+
+	// FullTransitiveCompatibility level requires both backward and forward
+	// compatibility with all previously registered schemas
+	FullTransitiveCompatibility CompatibilityLevel = "FULL_TRANSITIVE"
+
+	// NoneCompatibility level disables schema compatibility checks
+	NoneCompatibility CompatibilityLevel = "NONE"
+)
+
+// Mode defines schema registry mode
+type Mode string
+
+func (m Mode) String() string {
+	return string(m)
+}
+
+const (
+	// ReadWriteMode allows both reading and registering schemas
+	ReadWriteMode Mode = "READWRITE"
+
+	// ReadOnlyMode rejects any changes of schemas
+	ReadOnlyMode Mode = "READONLY"
+
+	// ImportMode allows registering schemas with explicit schema IDs and versions
+	ImportMode Mode = "IMPORT"
+)
+
 /*Reference defines struct for schema registry references
 
 In case of protobuf these are imported schema files and in case
@@ -35,8 +90,21 @@ type Reference struct {
 	Version int    `json:"version"`
 }
 
+// SubjectVersion identifies version of subject
+type SubjectVersion struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+func (v SubjectVersion) String() string {
+	return fmt.Sprintf("%s/%d", v.Subject, v.Version)
+}
+
 // Schema is a data structure that holds all
 // the relevant information about schemas.
+//
+// ID and Version are ignored when creating schema and are only used when
+// importing schema.
 type Schema struct {
 	ID         int         `json:"id,omitempty"`
 	Schema     string      `json:"schema,omitempty"`
@@ -65,14 +133,31 @@ func (s *Schema) GetRawSchema() (rawSchema string) {
 type Client interface {
 	GetSubjects(ctx context.Context) ([]string, error)
 	GetSubjectVersions(ctx context.Context, subject string) ([]int, error)
+	GetAllSubjectVersions(ctx context.Context, subject string) ([]int, error)
 	GetSchemaByID(ctx context.Context, schemaID int) (*Schema, error)
 	GetSchemaByVersion(ctx context.Context, subject string, version int) (*Schema, error)
 	GetSchemaSubjectVersions(ctx context.Context, schemaID int) (map[string]int, error)
+	GetSchemaVersions(ctx context.Context, schemaID int) ([]SubjectVersion, error)
+	GetReferencedBy(ctx context.Context, subject string, version int) ([]int, error)
 	GetLatestSchema(ctx context.Context, subject string) (*Schema, error)
 	CreateSchema(ctx context.Context, schema *Schema) (*Schema, error)
+	ImportSchema(ctx context.Context, schema *Schema) (*Schema, error)
+	LookupSchema(ctx context.Context, schema *Schema) (*Schema, error)
 	DeleteSubject(ctx context.Context, subject string, permanent bool) ([]int, error)
 	DeleteSchemaByVersion(ctx context.Context, subject string, version int, permanent bool) (int, error)
 	IsSchemaCompatible(ctx context.Context, schema *Schema) (bool, error)
+	CheckSchemaCompatibility(ctx context.Context, schema *Schema, allVersions bool) (*CompatibilityResult, error)
+	GetGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error)
+	SetGlobalCompatibilityLevel(ctx context.Context, level CompatibilityLevel) error
+	DeleteGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error)
+	GetCompatibilityLevel(ctx context.Context, subject string, defaultToGlobal bool) (CompatibilityLevel, error)
+	SetCompatibilityLevel(ctx context.Context, subject string, level CompatibilityLevel) error
+	DeleteCompatibilityLevel(ctx context.Context, subject string) (CompatibilityLevel, error)
+	GetGlobalMode(ctx context.Context) (Mode, error)
+	SetGlobalMode(ctx context.Context, mode Mode, force bool) error
+	GetMode(ctx context.Context, subject string, defaultToGlobal bool) (Mode, error)
+	SetMode(ctx context.Context, subject string, mode Mode, force bool) error
+	DeleteMode(ctx context.Context, subject string) (Mode, error)
 }
 
 // Option interface is here, so we can type check if valid arg is parsed as Option to client
@@ -93,8 +178,20 @@ func WithCaching(enable ...bool) GlobalOption {
 	}
 }
 
-// NewClient creates a new Client with optional caching
+// NewClient creates a new Client with optional caching, panicking if TLS
+// options cannot be applied, see NewClientWithError
 func NewClient(opts ...Option) Client {
+	client, err := NewClientWithError(opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return client
+}
+
+// NewClientWithError creates a new Client like NewClient, but returns error
+// if TLS options cannot be applied
+func NewClientWithError(opts ...Option) (Client, error) {
 	globals := &globalOptions{}
 	baseClientOpts := []BaseClientOption{}
 	cachingClientOpts := []CachingClientOption{}
@@ -109,11 +206,14 @@ func NewClient(opts ...Option) Client {
 		}
 	}
 
-	baseClient := NewBaseClient(baseClientOpts...)
+	baseClient, err := NewBaseClientWithError(baseClientOpts...)
+	if err != nil {
+		return nil, err
+	}
 
 	if globals.enableCaching {
-		return NewCachingClient(baseClient, cachingClientOpts...)
+		return NewCachingClient(baseClient, cachingClientOpts...), nil
 	}
 
-	return baseClient
+	return baseClient, nil
 }
@@ -59,6 +59,19 @@ func (mr *MockClientMockRecorder) GetSubjectVersions(ctx, subject interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubjectVersions", reflect.TypeOf((*MockClient)(nil).GetSubjectVersions), ctx, subject)
 }
 
+// GetAllSubjectVersions mocks base method
+func (m *MockClient) GetAllSubjectVersions(ctx context.Context, subject string) ([]int, error) {
+	ret := m.ctrl.Call(m, "GetAllSubjectVersions", ctx, subject)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSubjectVersions indicates an expected call of GetAllSubjectVersions
+func (mr *MockClientMockRecorder) GetAllSubjectVersions(ctx, subject interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSubjectVersions", reflect.TypeOf((*MockClient)(nil).GetAllSubjectVersions), ctx, subject)
+}
+
 // GetSchemaByID mocks base method
 func (m *MockClient) GetSchemaByID(ctx context.Context, schemaID int) (*Schema, error) {
 	ret := m.ctrl.Call(m, "GetSchemaByID", ctx, schemaID)
@@ -98,6 +111,32 @@ func (mr *MockClientMockRecorder) GetSchemaSubjectVersions(ctx, schemaID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaSubjectVersions", reflect.TypeOf((*MockClient)(nil).GetSchemaSubjectVersions), ctx, schemaID)
 }
 
+// GetSchemaVersions mocks base method
+func (m *MockClient) GetSchemaVersions(ctx context.Context, schemaID int) ([]SubjectVersion, error) {
+	ret := m.ctrl.Call(m, "GetSchemaVersions", ctx, schemaID)
+	ret0, _ := ret[0].([]SubjectVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchemaVersions indicates an expected call of GetSchemaVersions
+func (mr *MockClientMockRecorder) GetSchemaVersions(ctx, schemaID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchemaVersions", reflect.TypeOf((*MockClient)(nil).GetSchemaVersions), ctx, schemaID)
+}
+
+// GetReferencedBy mocks base method
+func (m *MockClient) GetReferencedBy(ctx context.Context, subject string, version int) ([]int, error) {
+	ret := m.ctrl.Call(m, "GetReferencedBy", ctx, subject, version)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferencedBy indicates an expected call of GetReferencedBy
+func (mr *MockClientMockRecorder) GetReferencedBy(ctx, subject, version interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferencedBy", reflect.TypeOf((*MockClient)(nil).GetReferencedBy), ctx, subject, version)
+}
+
 // GetLatestSchema mocks base method
 func (m *MockClient) GetLatestSchema(ctx context.Context, subject string) (*Schema, error) {
 	ret := m.ctrl.Call(m, "GetLatestSchema", ctx, subject)
@@ -124,6 +163,32 @@ func (mr *MockClientMockRecorder) CreateSchema(ctx, schema interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchema", reflect.TypeOf((*MockClient)(nil).CreateSchema), ctx, schema)
 }
 
+// ImportSchema mocks base method
+func (m *MockClient) ImportSchema(ctx context.Context, schema *Schema) (*Schema, error) {
+	ret := m.ctrl.Call(m, "ImportSchema", ctx, schema)
+	ret0, _ := ret[0].(*Schema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportSchema indicates an expected call of ImportSchema
+func (mr *MockClientMockRecorder) ImportSchema(ctx, schema interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportSchema", reflect.TypeOf((*MockClient)(nil).ImportSchema), ctx, schema)
+}
+
+// LookupSchema mocks base method
+func (m *MockClient) LookupSchema(ctx context.Context, schema *Schema) (*Schema, error) {
+	ret := m.ctrl.Call(m, "LookupSchema", ctx, schema)
+	ret0, _ := ret[0].(*Schema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookupSchema indicates an expected call of LookupSchema
+func (mr *MockClientMockRecorder) LookupSchema(ctx, schema interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupSchema", reflect.TypeOf((*MockClient)(nil).LookupSchema), ctx, schema)
+}
+
 // DeleteSubject mocks base method
 func (m *MockClient) DeleteSubject(ctx context.Context, subject string, permanent bool) ([]int, error) {
 	ret := m.ctrl.Call(m, "DeleteSubject", ctx, subject, permanent)
@@ -163,35 +228,187 @@ func (mr *MockClientMockRecorder) IsSchemaCompatible(ctx, schema interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSchemaCompatible", reflect.TypeOf((*MockClient)(nil).IsSchemaCompatible), ctx, schema)
 }
 
-// Mockoption is a mock of option interface
-type Mockoption struct {
+// CheckSchemaCompatibility mocks base method
+func (m *MockClient) CheckSchemaCompatibility(ctx context.Context, schema *Schema, allVersions bool) (*CompatibilityResult, error) {
+	ret := m.ctrl.Call(m, "CheckSchemaCompatibility", ctx, schema, allVersions)
+	ret0, _ := ret[0].(*CompatibilityResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckSchemaCompatibility indicates an expected call of CheckSchemaCompatibility
+func (mr *MockClientMockRecorder) CheckSchemaCompatibility(ctx, schema, allVersions interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSchemaCompatibility", reflect.TypeOf((*MockClient)(nil).CheckSchemaCompatibility), ctx, schema, allVersions)
+}
+
+// GetGlobalCompatibilityLevel mocks base method
+func (m *MockClient) GetGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error) {
+	ret := m.ctrl.Call(m, "GetGlobalCompatibilityLevel", ctx)
+	ret0, _ := ret[0].(CompatibilityLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGlobalCompatibilityLevel indicates an expected call of GetGlobalCompatibilityLevel
+func (mr *MockClientMockRecorder) GetGlobalCompatibilityLevel(ctx interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGlobalCompatibilityLevel", reflect.TypeOf((*MockClient)(nil).GetGlobalCompatibilityLevel), ctx)
+}
+
+// SetGlobalCompatibilityLevel mocks base method
+func (m *MockClient) SetGlobalCompatibilityLevel(ctx context.Context, level CompatibilityLevel) error {
+	ret := m.ctrl.Call(m, "SetGlobalCompatibilityLevel", ctx, level)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGlobalCompatibilityLevel indicates an expected call of SetGlobalCompatibilityLevel
+func (mr *MockClientMockRecorder) SetGlobalCompatibilityLevel(ctx, level interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGlobalCompatibilityLevel", reflect.TypeOf((*MockClient)(nil).SetGlobalCompatibilityLevel), ctx, level)
+}
+
+// DeleteGlobalCompatibilityLevel mocks base method
+func (m *MockClient) DeleteGlobalCompatibilityLevel(ctx context.Context) (CompatibilityLevel, error) {
+	ret := m.ctrl.Call(m, "DeleteGlobalCompatibilityLevel", ctx)
+	ret0, _ := ret[0].(CompatibilityLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteGlobalCompatibilityLevel indicates an expected call of DeleteGlobalCompatibilityLevel
+func (mr *MockClientMockRecorder) DeleteGlobalCompatibilityLevel(ctx interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGlobalCompatibilityLevel", reflect.TypeOf((*MockClient)(nil).DeleteGlobalCompatibilityLevel), ctx)
+}
+
+// GetCompatibilityLevel mocks base method
+func (m *MockClient) GetCompatibilityLevel(ctx context.Context, subject string, defaultToGlobal bool) (CompatibilityLevel, error) {
+	ret := m.ctrl.Call(m, "GetCompatibilityLevel", ctx, subject, defaultToGlobal)
+	ret0, _ := ret[0].(CompatibilityLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompatibilityLevel indicates an expected call of GetCompatibilityLevel
+func (mr *MockClientMockRecorder) GetCompatibilityLevel(ctx, subject, defaultToGlobal interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompatibilityLevel", reflect.TypeOf((*MockClient)(nil).GetCompatibilityLevel), ctx, subject, defaultToGlobal)
+}
+
+// SetCompatibilityLevel mocks base method
+func (m *MockClient) SetCompatibilityLevel(ctx context.Context, subject string, level CompatibilityLevel) error {
+	ret := m.ctrl.Call(m, "SetCompatibilityLevel", ctx, subject, level)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCompatibilityLevel indicates an expected call of SetCompatibilityLevel
+func (mr *MockClientMockRecorder) SetCompatibilityLevel(ctx, subject, level interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCompatibilityLevel", reflect.TypeOf((*MockClient)(nil).SetCompatibilityLevel), ctx, subject, level)
+}
+
+// DeleteCompatibilityLevel mocks base method
+func (m *MockClient) DeleteCompatibilityLevel(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	ret := m.ctrl.Call(m, "DeleteCompatibilityLevel", ctx, subject)
+	ret0, _ := ret[0].(CompatibilityLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCompatibilityLevel indicates an expected call of DeleteCompatibilityLevel
+func (mr *MockClientMockRecorder) DeleteCompatibilityLevel(ctx, subject interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCompatibilityLevel", reflect.TypeOf((*MockClient)(nil).DeleteCompatibilityLevel), ctx, subject)
+}
+
+// GetGlobalMode mocks base method
+func (m *MockClient) GetGlobalMode(ctx context.Context) (Mode, error) {
+	ret := m.ctrl.Call(m, "GetGlobalMode", ctx)
+	ret0, _ := ret[0].(Mode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGlobalMode indicates an expected call of GetGlobalMode
+func (mr *MockClientMockRecorder) GetGlobalMode(ctx interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGlobalMode", reflect.TypeOf((*MockClient)(nil).GetGlobalMode), ctx)
+}
+
+// SetGlobalMode mocks base method
+func (m *MockClient) SetGlobalMode(ctx context.Context, mode Mode, force bool) error {
+	ret := m.ctrl.Call(m, "SetGlobalMode", ctx, mode, force)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGlobalMode indicates an expected call of SetGlobalMode
+func (mr *MockClientMockRecorder) SetGlobalMode(ctx, mode, force interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGlobalMode", reflect.TypeOf((*MockClient)(nil).SetGlobalMode), ctx, mode, force)
+}
+
+// GetMode mocks base method
+func (m *MockClient) GetMode(ctx context.Context, subject string, defaultToGlobal bool) (Mode, error) {
+	ret := m.ctrl.Call(m, "GetMode", ctx, subject, defaultToGlobal)
+	ret0, _ := ret[0].(Mode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMode indicates an expected call of GetMode
+func (mr *MockClientMockRecorder) GetMode(ctx, subject, defaultToGlobal interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMode", reflect.TypeOf((*MockClient)(nil).GetMode), ctx, subject, defaultToGlobal)
+}
+
+// SetMode mocks base method
+func (m *MockClient) SetMode(ctx context.Context, subject string, mode Mode, force bool) error {
+	ret := m.ctrl.Call(m, "SetMode", ctx, subject, mode, force)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMode indicates an expected call of SetMode
+func (mr *MockClientMockRecorder) SetMode(ctx, subject, mode, force interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMode", reflect.TypeOf((*MockClient)(nil).SetMode), ctx, subject, mode, force)
+}
+
+// DeleteMode mocks base method
+func (m *MockClient) DeleteMode(ctx context.Context, subject string) (Mode, error) {
+	ret := m.ctrl.Call(m, "DeleteMode", ctx, subject)
+	ret0, _ := ret[0].(Mode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMode indicates an expected call of DeleteMode
+func (mr *MockClientMockRecorder) DeleteMode(ctx, subject interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMode", reflect.TypeOf((*MockClient)(nil).DeleteMode), ctx, subject)
+}
+
+// MockOption is a mock of Option interface
+type MockOption struct {
 	ctrl     *gomock.Controller
-	recorder *MockoptionMockRecorder
+	recorder *MockOptionMockRecorder
 }
 
-// MockoptionMockRecorder is the mock recorder for Mockoption
-type MockoptionMockRecorder struct {
-	mock *Mockoption
+// MockOptionMockRecorder is the mock recorder for MockOption
+type MockOptionMockRecorder struct {
+	mock *MockOption
 }
 
-// NewMockoption creates a new mock instance
-func NewMockoption(ctrl *gomock.Controller) *Mockoption {
-	mock := &Mockoption{ctrl: ctrl}
-	mock.recorder = &MockoptionMockRecorder{mock}
+// NewMockOption creates a new mock instance
+func NewMockOption(ctrl *gomock.Controller) *MockOption {
+	mock := &MockOption{ctrl: ctrl}
+	mock.recorder = &MockOptionMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use
-func (m *Mockoption) EXPECT() *MockoptionMockRecorder {
+func (m *MockOption) EXPECT() *MockOptionMockRecorder {
 	return m.recorder
 }
 
 // OptionType mocks base method
-func (m *Mockoption) OptionType() {
+func (m *MockOption) OptionType() {
 	m.ctrl.Call(m, "OptionType")
 }
 
 // OptionType indicates an expected call of OptionType
-func (mr *MockoptionMockRecorder) OptionType() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OptionType", reflect.TypeOf((*Mockoption)(nil).OptionType))
+func (mr *MockOptionMockRecorder) OptionType() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OptionType", reflect.TypeOf((*MockOption)(nil).OptionType))
 }
@@ -17,11 +17,11 @@ func withRandomSubject(s *Schema) {
 }
 
 func withRandomID(s *Schema) {
-	s.ID = rand.Intn(100)
+	s.ID = 1 + rand.Intn(100)
 }
 
 func withRandomVersion(s *Schema) {
-	s.Version = rand.Intn(100)
+	s.Version = 1 + rand.Intn(100)
 }
 
 func withTestReferences(s *Schema) {
@@ -80,8 +80,7 @@ func TestNewClient(t *testing.T) {
 
 	cachingClient := c.(*CachingClient)
 
-	require.NotNil(t, cachingClient.cache.cache)
-	require.NotNil(t, cachingClient.cache.infcache)
+	require.NotNil(t, cachingClient.cache.backend)
 
 	require.IsType(t, &BaseClient{}, cachingClient.client)
 
@@ -0,0 +1,152 @@
+package srclient
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CompatibilityMessage is incompatibility message returned by verbose
+// compatibility check
+type CompatibilityMessage struct {
+	// ErrorType is type of incompatibility, like FIELD_KIND_CHANGED
+	ErrorType string
+
+	// Description is human readable description of incompatibility
+	Description string
+
+	// AdditionalInfo is additional information about incompatibility
+	AdditionalInfo string
+
+	// OldSchemaVersion is version of schema that new schema is
+	// incompatible with
+	OldSchemaVersion int
+
+	// OldSchema is schema that new schema is incompatible with
+	OldSchema string
+
+	// Compatibility is compatibility level used for check
+	Compatibility CompatibilityLevel
+
+	// Raw is message as returned by schema registry
+	Raw string
+}
+
+func (m CompatibilityMessage) String() string {
+	b := &strings.Builder{}
+
+	if m.ErrorType != "" {
+		fmt.Fprintf(b, "%s: ", m.ErrorType)
+	}
+
+	b.WriteString(m.Description)
+
+	if m.AdditionalInfo != "" {
+		fmt.Fprintf(b, " (%s)", m.AdditionalInfo)
+	}
+
+	if m.OldSchemaVersion != 0 {
+		fmt.Fprintf(b, " [version %d", m.OldSchemaVersion)
+		if m.Compatibility != "" {
+			fmt.Fprintf(b, ", %s", m.Compatibility)
+		}
+		b.WriteString("]")
+	}
+
+	return b.String()
+}
+
+// CompatibilityResult is result of verbose compatibility check
+type CompatibilityResult struct {
+	// IsCompatible is whether schema is compatible
+	IsCompatible bool
+
+	// Messages are incompatibility messages
+	Messages []CompatibilityMessage
+}
+
+var compatibilityMessageKeyRegexp = regexp.MustCompile(
+	`(?:^|,\s*)(errorType|description|additionalInfo|oldSchemaVersion|oldSchema|compatibility)\s*:\s*`)
+
+// parseCompatibilityMessage parses compatibility message, which is in
+// format of "{errorType:'...', description:'...'}"
+func parseCompatibilityMessage(msg string) map[string]string {
+	body := strings.TrimSpace(msg)
+	if !strings.HasPrefix(body, "{") || !strings.HasSuffix(body, "}") {
+		return nil
+	}
+
+	body = body[1 : len(body)-1]
+
+	matches := compatibilityMessageKeyRegexp.FindAllStringSubmatchIndex(body, -1)
+	if len(matches) == 0 || matches[0][0] != 0 {
+		return nil
+	}
+
+	fields := map[string]string{}
+	for i, match := range matches {
+		end := len(body)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		value := strings.TrimSpace(body[match[1]:end])
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		fields[body[match[2]:match[3]]] = value
+	}
+
+	return fields
+}
+
+// parseCompatibilityMessages parses verbose compatibility messages
+//
+// Schema registry returns incompatibility messages followed by messages with
+// old schema version, old schema and compatibility level they apply to, which
+// are merged into preceding incompatibility messages.
+func parseCompatibilityMessages(raw []string) []CompatibilityMessage {
+	messages := []CompatibilityMessage{}
+	groupStart, contextSeen := 0, false
+
+	for _, msg := range raw {
+		fields := parseCompatibilityMessage(msg)
+
+		_, hasErrorType := fields["errorType"]
+		_, hasDescription := fields["description"]
+		if fields == nil || hasErrorType || hasDescription {
+			if contextSeen {
+				groupStart, contextSeen = len(messages), false
+			}
+
+			message := CompatibilityMessage{Raw: msg, Description: msg}
+			if fields != nil {
+				message.ErrorType = fields["errorType"]
+				message.Description = fields["description"]
+				message.AdditionalInfo = fields["additionalInfo"]
+			}
+
+			messages = append(messages, message)
+			continue
+		}
+
+		contextSeen = true
+		for i := groupStart; i < len(messages); i++ {
+			if version, ok := fields["oldSchemaVersion"]; ok {
+				messages[i].OldSchemaVersion, _ = strconv.Atoi(version)
+			}
+
+			if schema, ok := fields["oldSchema"]; ok {
+				messages[i].OldSchema = schema
+			}
+
+			if level, ok := fields["compatibility"]; ok {
+				messages[i].Compatibility = CompatibilityLevel(level)
+			}
+		}
+	}
+
+	return messages
+}
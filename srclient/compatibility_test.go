@@ -0,0 +1,46 @@
+package srclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompatibilityMessages(t *testing.T) {
+	messages := parseCompatibilityMessages([]string{
+		"{errorType:'FIELD_KIND_CHANGED', description:'The kind of field 'key' at path '#/Test/1' changed', additionalInfo:'string -> int32'}",
+		"{errorType:\"FIELD_REMOVED\", description:\"Field removed, breaks compatibility\"}",
+		"{oldSchemaVersion: 2}",
+		"{oldSchema: 'syntax = \"proto3\"; message Test { string key = 1; }'}",
+		"{compatibility: 'BACKWARD_TRANSITIVE'}",
+		"{errorType:'MESSAGE_REMOVED', description:'Message removed'}",
+		"{oldSchemaVersion: 1}",
+		"{compatibility: 'BACKWARD_TRANSITIVE'}",
+		"Incompatibility{type:FIELD_KIND_CHANGED, location:#/Test/1}",
+	})
+
+	require.Len(t, messages, 4)
+
+	require.Equal(t, "FIELD_KIND_CHANGED", messages[0].ErrorType)
+	require.Equal(t, "The kind of field 'key' at path '#/Test/1' changed", messages[0].Description)
+	require.Equal(t, "string -> int32", messages[0].AdditionalInfo)
+	require.Equal(t, 2, messages[0].OldSchemaVersion)
+	require.Equal(t, `syntax = "proto3"; message Test { string key = 1; }`, messages[0].OldSchema)
+	require.Equal(t, BackwardTransitiveCompatibility, messages[0].Compatibility)
+	require.Equal(t,
+		"FIELD_KIND_CHANGED: The kind of field 'key' at path '#/Test/1' changed (string -> int32) [version 2, BACKWARD_TRANSITIVE]",
+		messages[0].String())
+
+	require.Equal(t, "FIELD_REMOVED", messages[1].ErrorType)
+	require.Equal(t, "Field removed, breaks compatibility", messages[1].Description)
+	require.Equal(t, 2, messages[1].OldSchemaVersion)
+
+	require.Equal(t, "MESSAGE_REMOVED", messages[2].ErrorType)
+	require.Equal(t, 1, messages[2].OldSchemaVersion)
+	require.Empty(t, messages[2].OldSchema)
+
+	// messages in unknown format are returned as description
+	require.Empty(t, messages[3].ErrorType)
+	require.Equal(t, messages[3].Raw, messages[3].Description)
+	require.Equal(t, messages[3].Raw, messages[3].String())
+}
@@ -0,0 +1,243 @@
+package srclient
+
+import (
+	"container/list"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	diskFileSchemaByID      = "id-%d.json"
+	diskFileSchemaByVersion = "version-%s-%d.json"
+	diskFileTempPrefix      = ".tmp-"
+)
+
+type diskFile struct {
+	name    string
+	subject string
+	size    int64
+}
+
+// diskCache persists immutable entries, schemas by id and subject versions,
+// to directory, so they are available after restart even if schema registry
+// is unavailable
+//
+// Files are written atomically by renaming temporary files, and when size of
+// files exceeds maximum size, oldest files are removed. Errors writing and
+// removing files are ignored, as persisted entries are only a cache.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	files map[string]*list.Element
+	order *list.List
+}
+
+// openDiskCache opens disk cache in directory, creating it if it does not
+// exist, and returns schemas persisted in it
+func openDiskCache(dir string, maxBytes int64) (*diskCache, []*Schema, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading cache directory: %w", err)
+	}
+
+	c := &diskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		files:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+
+	// load oldest files first, so they are removed first
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	loaded := map[string]*Schema{}
+	for _, info := range infos {
+		name := info.Name()
+
+		if info.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		// remove temporary files left by interrupted writes
+		if strings.HasPrefix(name, diskFileTempPrefix) {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+
+		schema, err := c.read(name)
+		if err != nil {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+
+		c.add(name, schema.Subject, info.Size())
+		loaded[name] = schema
+	}
+
+	c.evict()
+
+	schemas := []*Schema{}
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		schemas = append(schemas, loaded[el.Value.(*diskFile).name])
+	}
+
+	return c, schemas, nil
+}
+
+func diskFileNameByVersion(subject string, version int) string {
+	return fmt.Sprintf(diskFileSchemaByVersion, base64.RawURLEncoding.EncodeToString([]byte(subject)), version)
+}
+
+// read reads schema from file, checking that file name matches schema
+func (c *diskCache) read(name string) (*Schema, error) {
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, err
+	}
+
+	if name != fmt.Sprintf(diskFileSchemaByID, schema.ID) && name != diskFileNameByVersion(schema.Subject, schema.Version) {
+		return nil, fmt.Errorf("file name does not match schema")
+	}
+
+	return schema, nil
+}
+
+// Store persists schema under its id and subject version
+func (c *diskCache) Store(schema *Schema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if schema.ID > 0 {
+		c.write(fmt.Sprintf(diskFileSchemaByID, schema.ID), schema)
+	}
+
+	if schema.Subject != "" && schema.Version > 0 {
+		c.write(diskFileNameByVersion(schema.Subject, schema.Version), schema)
+	}
+
+	c.evict()
+}
+
+// write writes schema to file, unless file already exists, must be called
+// with lock held
+func (c *diskCache) write(name string, schema *Schema) {
+	if _, ok := c.files[name]; ok {
+		return
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return
+	}
+
+	f, err := ioutil.TempFile(c.dir, diskFileTempPrefix+"*.json")
+	if err != nil {
+		return
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, name))
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+
+	c.add(name, schema.Subject, int64(len(data)))
+}
+
+// add adds file to size accounting, must be called with lock held
+func (c *diskCache) add(name string, subject string, size int64) {
+	c.files[name] = c.order.PushBack(&diskFile{name: name, subject: subject, size: size})
+	c.size += size
+}
+
+// evict removes oldest files, while size of files exceeds maximum size,
+// must be called with lock held
+func (c *diskCache) evict() {
+	for c.maxBytes > 0 && c.size > c.maxBytes && c.order.Len() > 0 {
+		c.remove(c.order.Front().Value.(*diskFile).name)
+	}
+}
+
+// remove removes file, must be called with lock held
+func (c *diskCache) remove(name string) {
+	el, ok := c.files[name]
+	if !ok {
+		return
+	}
+
+	os.Remove(filepath.Join(c.dir, name))
+
+	c.size -= el.Value.(*diskFile).size
+	c.order.Remove(el)
+	delete(c.files, name)
+}
+
+// RemoveSchemaByID removes schema persisted under id
+func (c *diskCache) RemoveSchemaByID(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(fmt.Sprintf(diskFileSchemaByID, id))
+}
+
+// RemoveSchemaByVersion removes schema persisted under subject version
+func (c *diskCache) RemoveSchemaByVersion(subject string, version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(diskFileNameByVersion(subject, version))
+}
+
+// RemoveSubject removes all schemas persisted under subject versions
+func (c *diskCache) RemoveSubject(subject string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := strings.TrimSuffix(diskFileNameByVersion(subject, 0), "0.json")
+	for name, el := range c.files {
+		if strings.HasPrefix(name, prefix) && el.Value.(*diskFile).subject == subject {
+			c.remove(name)
+		}
+	}
+}
+
+// Size returns size of persisted files
+func (c *diskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
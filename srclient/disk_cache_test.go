@@ -0,0 +1,103 @@
+package srclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func diskCacheFiles(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	c, schemas, err := openDiskCache(dir, 0)
+	require.NoError(t, err)
+	require.Empty(t, schemas)
+
+	s1 := &Schema{ID: 1, Subject: "s1", Version: 1, Schema: "schema1"}
+	s2 := &Schema{ID: 2, Subject: "s/2", Version: 1, Schema: "schema2"}
+	s3 := &Schema{ID: 3, Schema: "schema3"}
+
+	c.Store(s1)
+	c.Store(s2)
+	c.Store(s3)
+
+	require.Equal(t, []string{
+		"id-1.json",
+		"id-2.json",
+		"id-3.json",
+		diskFileNameByVersion("s/2", 1),
+		diskFileNameByVersion("s1", 1),
+	}, diskCacheFiles(t, dir))
+
+	// leftover temporary and corrupted files are removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, diskFileTempPrefix+"1.json"), []byte("{"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "id-4.json"), []byte("{"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "id-5.json"), []byte(`{"id": 1}`), 0600))
+
+	size := c.Size()
+	c, schemas, err = openDiskCache(dir, 0)
+	require.NoError(t, err)
+	require.Len(t, schemas, 5)
+	require.Contains(t, schemas, s1)
+	require.Contains(t, schemas, s2)
+	require.Contains(t, schemas, s3)
+	require.Equal(t, size, c.Size())
+	require.Len(t, diskCacheFiles(t, dir), 5)
+
+	c.RemoveSubject("s/2")
+	c.RemoveSchemaByVersion("s1", 1)
+	c.RemoveSchemaByID(3)
+
+	require.Equal(t, []string{"id-1.json", "id-2.json"}, diskCacheFiles(t, dir))
+}
+
+func TestDiskCacheMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+
+	schema := func(id int) *Schema {
+		return &Schema{ID: id, Schema: fmt.Sprintf("schema %d", id)}
+	}
+
+	c, _, err := openDiskCache(dir, 0)
+	require.NoError(t, err)
+
+	c.Store(schema(1))
+	size := c.Size()
+
+	c, _, err = openDiskCache(dir, 2*size)
+	require.NoError(t, err)
+
+	// oldest files are removed, when size exceeds maximum size
+	c.Store(schema(2))
+	c.Store(schema(3))
+	require.Equal(t, []string{"id-2.json", "id-3.json"}, diskCacheFiles(t, dir))
+	require.Equal(t, 2*size, c.Size())
+
+	// files are removed by modification time, when cache is opened
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "id-3.json"), old, old))
+
+	c, schemas, err := openDiskCache(dir, size)
+	require.NoError(t, err)
+	require.Equal(t, []*Schema{schema(2)}, schemas)
+	require.Equal(t, []string{"id-2.json"}, diskCacheFiles(t, dir))
+}
@@ -0,0 +1,217 @@
+package srclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned for all not found errors
+var ErrNotFound = errors.New("404 not found")
+
+var (
+	// ErrSubjectNotFound is returned when subject does not exist
+	ErrSubjectNotFound = errors.New("subject not found")
+
+	// ErrVersionNotFound is returned when subject version does not exist
+	ErrVersionNotFound = errors.New("version not found")
+
+	// ErrSchemaNotFound is returned when schema does not exist
+	ErrSchemaNotFound = errors.New("schema not found")
+
+	// ErrSubjectSoftDeleted is returned when subject was already soft deleted
+	ErrSubjectSoftDeleted = errors.New("subject soft deleted")
+
+	// ErrSubjectNotSoftDeleted is returned when permanently deleting subject,
+	// that was not soft deleted first
+	ErrSubjectNotSoftDeleted = errors.New("subject not soft deleted")
+
+	// ErrSchemaVersionSoftDeleted is returned when schema version was already
+	// soft deleted
+	ErrSchemaVersionSoftDeleted = errors.New("schema version soft deleted")
+
+	// ErrSchemaVersionNotSoftDeleted is returned when permanently deleting
+	// schema version, that was not soft deleted first
+	ErrSchemaVersionNotSoftDeleted = errors.New("schema version not soft deleted")
+
+	// ErrSubjectLevelCompatibilityNotConfigured is returned when subject has
+	// no compatibility level configured
+	ErrSubjectLevelCompatibilityNotConfigured = errors.New("subject level compatibility not configured")
+
+	// ErrSubjectLevelModeNotConfigured is returned when subject has no mode
+	// configured
+	ErrSubjectLevelModeNotConfigured = errors.New("subject level mode not configured")
+
+	// ErrIncompatibleSchema is returned when registering schema, that is
+	// incompatible with already registered schemas
+	ErrIncompatibleSchema = errors.New("incompatible schema")
+
+	// ErrInvalidSchema is returned when registering invalid schema
+	ErrInvalidSchema = errors.New("invalid schema")
+
+	// ErrInvalidVersion is returned when using invalid subject version
+	ErrInvalidVersion = errors.New("invalid version")
+
+	// ErrInvalidCompatibilityLevel is returned when setting invalid
+	// compatibility level
+	ErrInvalidCompatibilityLevel = errors.New("invalid compatibility level")
+
+	// ErrInvalidMode is returned when setting invalid schema registry mode
+	ErrInvalidMode = errors.New("invalid mode")
+
+	// ErrOperationNotPermitted is returned when operation is not permitted in
+	// current schema registry mode, like registering schemas in read-only mode
+	ErrOperationNotPermitted = errors.New("operation not permitted")
+
+	// ErrReferenceExists is returned when deleting schema, that is referenced
+	// by other schemas
+	ErrReferenceExists = errors.New("reference exists")
+
+	// ErrIDDoesNotMatch is returned when importing schema with id, that does
+	// not match id of already registered schema
+	ErrIDDoesNotMatch = errors.New("id does not match")
+
+	// ErrUnauthorized is returned when schema registry credentials are invalid
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden is returned when schema registry credentials do not allow
+	// operation
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrServerError is returned for all schema registry server errors
+	ErrServerError = errors.New("server error")
+
+	// ErrBackendStore is returned when schema registry backend store fails
+	ErrBackendStore = errors.New("backend store error")
+
+	// ErrOperationTimeout is returned when schema registry operation times out
+	ErrOperationTimeout = errors.New("operation timeout")
+
+	// ErrRequestForwarding is returned when schema registry fails to forward
+	// request to leader
+	ErrRequestForwarding = errors.New("request forwarding error")
+)
+
+// ErrStale is matched by errors returned together with stale values, when
+// expired values cannot be refreshed
+var ErrStale = errors.New("stale value")
+
+// StaleError is returned together with last known value by CachingClient,
+// when value expired and schema registry is unavailable
+type StaleError struct {
+	// Err is error returned while refreshing value
+	Err error
+
+	// Age is time since value expired
+	Age time.Duration
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("stale value expired %s ago: %s", e.Age, e.Err)
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// Is checks whether target is ErrStale
+func (e *StaleError) Is(target error) bool {
+	return target == ErrStale
+}
+
+// schema registry error codes
+const (
+	errCodeSubjectNotFound                 = 40401
+	errCodeVersionNotFound                 = 40402
+	errCodeSchemaNotFound                  = 40403
+	errCodeSubjectSoftDeleted              = 40404
+	errCodeSubjectNotSoftDeleted           = 40405
+	errCodeSchemaVersionSoftDeleted        = 40406
+	errCodeSchemaVersionNotSoftDeleted     = 40407
+	errCodeSubjectLevelCompatNotConfigured = 40408
+	errCodeSubjectLevelModeNotConfigured   = 40409
+	errCodeIncompatibleSchema              = 409
+	errCodeInvalidSchema                   = 42201
+	errCodeInvalidVersion                  = 42202
+	errCodeInvalidCompatibilityLevel       = 42203
+	errCodeInvalidMode                     = 42204
+	errCodeOperationNotPermitted           = 42205
+	errCodeReferenceExists                 = 42206
+	errCodeIDDoesNotMatch                  = 42207
+	errCodeBackendStore                    = 50001
+	errCodeOperationTimeout                = 50002
+	errCodeRequestForwarding               = 50003
+)
+
+var errorCodes = map[int]error{
+	errCodeSubjectNotFound:                 ErrSubjectNotFound,
+	errCodeVersionNotFound:                 ErrVersionNotFound,
+	errCodeSchemaNotFound:                  ErrSchemaNotFound,
+	errCodeSubjectSoftDeleted:              ErrSubjectSoftDeleted,
+	errCodeSubjectNotSoftDeleted:           ErrSubjectNotSoftDeleted,
+	errCodeSchemaVersionSoftDeleted:        ErrSchemaVersionSoftDeleted,
+	errCodeSchemaVersionNotSoftDeleted:     ErrSchemaVersionNotSoftDeleted,
+	errCodeSubjectLevelCompatNotConfigured: ErrSubjectLevelCompatibilityNotConfigured,
+	errCodeSubjectLevelModeNotConfigured:   ErrSubjectLevelModeNotConfigured,
+	errCodeIncompatibleSchema:              ErrIncompatibleSchema,
+	errCodeInvalidSchema:                   ErrInvalidSchema,
+	errCodeInvalidVersion:                  ErrInvalidVersion,
+	errCodeInvalidCompatibilityLevel:       ErrInvalidCompatibilityLevel,
+	errCodeInvalidMode:                     ErrInvalidMode,
+	errCodeOperationNotPermitted:           ErrOperationNotPermitted,
+	errCodeReferenceExists:                 ErrReferenceExists,
+	errCodeIDDoesNotMatch:                  ErrIDDoesNotMatch,
+	errCodeBackendStore:                    ErrBackendStore,
+	errCodeOperationTimeout:                ErrOperationTimeout,
+	errCodeRequestForwarding:               ErrRequestForwarding,
+}
+
+// RegistryError is error returned by schema registry
+//
+// Registry errors can be matched with errors.Is against sentinel errors
+// like ErrNotFound, ErrSubjectNotFound or ErrIncompatibleSchema.
+type RegistryError struct {
+	// StatusCode is HTTP status code of response
+	StatusCode int
+
+	// ErrorCode is schema registry error code, like 40401 for subject not
+	// found, or zero if response had no error code
+	ErrorCode int
+
+	// Message is schema registry error message
+	Message string
+}
+
+func (e *RegistryError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+
+	if e.ErrorCode != 0 {
+		return fmt.Sprintf("%s: %s (error code %d)", status, e.Message, e.ErrorCode)
+	}
+
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", status, e.Message)
+	}
+
+	return status
+}
+
+// Is checks whether registry error matches one of sentinel errors
+func (e *RegistryError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrIncompatibleSchema:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrServerError:
+		return e.StatusCode >= 500
+	}
+
+	err, ok := errorCodes[e.ErrorCode]
+	return ok && err == target
+}
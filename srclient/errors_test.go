@@ -0,0 +1,122 @@
+package srclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestCreateHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		errorCode  int
+		matches    []error
+		notMatches []error
+	}{
+		{
+			name:       "subject not found",
+			statusCode: 404,
+			body:       `{"error_code": 40401, "message": "Subject 'sub' not found."}`,
+			errorCode:  40401,
+			matches:    []error{ErrNotFound, ErrSubjectNotFound},
+			notMatches: []error{ErrVersionNotFound, ErrSchemaNotFound, ErrServerError},
+		},
+		{
+			name:       "schema not found",
+			statusCode: 404,
+			body:       `{"error_code": 40403, "message": "Schema 1 not found"}`,
+			errorCode:  40403,
+			matches:    []error{ErrNotFound, ErrSchemaNotFound},
+			notMatches: []error{ErrSubjectNotFound},
+		},
+		{
+			name:       "incompatible schema",
+			statusCode: 409,
+			body:       `{"error_code": 409, "message": "Schema being registered is incompatible"}`,
+			errorCode:  409,
+			matches:    []error{ErrIncompatibleSchema},
+			notMatches: []error{ErrNotFound, ErrInvalidSchema, ErrServerError},
+		},
+		{
+			name:       "invalid schema",
+			statusCode: 422,
+			body:       `{"error_code": 42201, "message": "Invalid schema"}`,
+			errorCode:  42201,
+			matches:    []error{ErrInvalidSchema},
+			notMatches: []error{ErrIncompatibleSchema, ErrOperationNotPermitted},
+		},
+		{
+			name:       "operation not permitted",
+			statusCode: 422,
+			body:       `{"error_code": 42205, "message": "Subject is in read-only mode"}`,
+			errorCode:  42205,
+			matches:    []error{ErrOperationNotPermitted},
+			notMatches: []error{ErrInvalidSchema},
+		},
+		{
+			name:       "backend store error",
+			statusCode: 500,
+			body:       `{"error_code": 50001, "message": "Error in the backend data store"}`,
+			errorCode:  50001,
+			matches:    []error{ErrServerError, ErrBackendStore},
+			notMatches: []error{ErrNotFound, ErrOperationTimeout},
+		},
+		{
+			name:       "unavailable without body",
+			statusCode: 503,
+			body:       `<html>Service Unavailable</html>`,
+			matches:    []error{ErrServerError},
+			notMatches: []error{ErrNotFound, ErrBackendStore},
+		},
+		{
+			name:       "unauthorized",
+			statusCode: 401,
+			body:       `{"error_code": 401, "message": "Unauthorized"}`,
+			errorCode:  401,
+			matches:    []error{ErrUnauthorized},
+			notMatches: []error{ErrForbidden, ErrServerError},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := fmt.Errorf("error getting schema: %w", createHTTPError(newTestResponse(test.statusCode, test.body)))
+
+			var registryErr *RegistryError
+			require.True(t, errors.As(err, &registryErr))
+			require.Equal(t, test.statusCode, registryErr.StatusCode)
+			require.Equal(t, test.errorCode, registryErr.ErrorCode)
+
+			for _, target := range test.matches {
+				require.True(t, errors.Is(err, target), "error must match %v", target)
+			}
+
+			for _, target := range test.notMatches {
+				require.False(t, errors.Is(err, target), "error must not match %v", target)
+			}
+		})
+	}
+}
+
+func TestRegistryErrorMessage(t *testing.T) {
+	err := &RegistryError{StatusCode: 404, ErrorCode: 40401, Message: "Subject 'sub' not found."}
+	require.Equal(t, "404 Not Found: Subject 'sub' not found. (error code 40401)", err.Error())
+
+	err = &RegistryError{StatusCode: 503}
+	require.Equal(t, "503 Service Unavailable", err.Error())
+}
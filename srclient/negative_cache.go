@@ -0,0 +1,106 @@
+package srclient
+
+import (
+	"sync"
+	"time"
+)
+
+// NegativeCacheTTL configures for how long not found errors are cached for
+// each kind of lookup, zero ttl disables caching of not found errors of that
+// kind
+type NegativeCacheTTL struct {
+	// SchemaByID is ttl of not found errors of schemas by id
+	SchemaByID time.Duration
+
+	// SchemaByVersion is ttl of not found errors of subject versions
+	SchemaByVersion time.Duration
+
+	// LatestSchema is ttl of not found errors of latest subject schemas
+	LatestSchema time.Duration
+}
+
+type negativeEntry struct {
+	err     error
+	subject string
+	id      int
+	expires time.Time
+}
+
+// negativeCache caches not found errors, so lookups of schemas that do not
+// exist are not repeated for every call
+type negativeCache struct {
+	ttl NegativeCacheTTL
+
+	mu      sync.Mutex
+	entries map[string]negativeEntry
+
+	// gen is incremented on every invalidation, so loads started before
+	// invalidation do not cache stale not found errors
+	gen uint64
+
+	now func() time.Time
+}
+
+// Get gets cached not found error under key and current cache generation
+func (c *negativeCache) Get(key string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+
+	if !ok {
+		return c.gen, nil
+	}
+
+	return c.gen, entry.err
+}
+
+// Put caches not found error under key for ttl, unless cache was invalidated
+// since generation gen was read
+func (c *negativeCache) Put(key string, ttl time.Duration, gen uint64, subject string, id int, err error) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen {
+		return
+	}
+
+	if c.entries == nil {
+		c.entries = map[string]negativeEntry{}
+	}
+
+	now := c.now()
+	c.entries[key] = negativeEntry{err: err, subject: subject, id: id, expires: now.Add(ttl)}
+
+	// purge expired entries, so lookups of many different missing schemas do
+	// not grow the cache without bounds
+	if len(c.entries)%128 == 0 {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+}
+
+// Invalidate removes not found errors cached for subject or schema id
+func (c *negativeCache) Invalidate(subject string, id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	for key, entry := range c.entries {
+		if (subject != "" && entry.subject == subject) || (id > 0 && entry.id == id) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ReferenceGraph is graph of schema references, where nodes are subject
+// versions and edges are references from subject versions to subject
+// versions they reference
+type ReferenceGraph struct {
+	// Schemas are schemas of subject versions, where referenced subject
+	// versions, that are not found, have no schema
+	Schemas map[SubjectVersion]*Schema
+
+	references   map[SubjectVersion][]SubjectVersion
+	referencedBy map[SubjectVersion][]SubjectVersion
+}
+
+func newReferenceGraph() *ReferenceGraph {
+	return &ReferenceGraph{
+		Schemas:      map[SubjectVersion]*Schema{},
+		references:   map[SubjectVersion][]SubjectVersion{},
+		referencedBy: map[SubjectVersion][]SubjectVersion{},
+	}
+}
+
+// addSchema adds schema of subject version and its references to graph
+func (g *ReferenceGraph) addSchema(node SubjectVersion, schema *Schema) {
+	g.Schemas[node] = schema
+
+	for _, ref := range schema.References {
+		to := SubjectVersion{Subject: ref.Subject, Version: ref.Version}
+		g.references[node] = append(g.references[node], to)
+		g.referencedBy[to] = append(g.referencedBy[to], node)
+	}
+}
+
+// Nodes returns sorted subject versions in graph
+func (g *ReferenceGraph) Nodes() []SubjectVersion {
+	nodes := []SubjectVersion{}
+	for node := range g.Schemas {
+		nodes = append(nodes, node)
+	}
+
+	for node := range g.referencedBy {
+		if _, ok := g.Schemas[node]; !ok {
+			nodes = append(nodes, node)
+		}
+	}
+
+	sortSubjectVersions(nodes)
+
+	return nodes
+}
+
+// References returns subject versions directly referenced by subject version
+func (g *ReferenceGraph) References(node SubjectVersion) []SubjectVersion {
+	return sortedSubjectVersions(g.references[node])
+}
+
+// ReferencedBy returns subject versions directly referencing subject version
+func (g *ReferenceGraph) ReferencedBy(node SubjectVersion) []SubjectVersion {
+	return sortedSubjectVersions(g.referencedBy[node])
+}
+
+// Dependents returns subject versions referencing subject version directly
+// or transitively, which have to be deleted before subject version can be
+// deleted
+func (g *ReferenceGraph) Dependents(node SubjectVersion) []SubjectVersion {
+	seen := map[SubjectVersion]bool{node: true}
+	queue := []SubjectVersion{node}
+	dependents := []SubjectVersion{}
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		for _, from := range g.referencedBy[next] {
+			if !seen[from] {
+				seen[from] = true
+				queue = append(queue, from)
+				dependents = append(dependents, from)
+			}
+		}
+	}
+
+	sortSubjectVersions(dependents)
+
+	return dependents
+}
+
+func sortedSubjectVersions(nodes []SubjectVersion) []SubjectVersion {
+	result := append([]SubjectVersion{}, nodes...)
+	sortSubjectVersions(result)
+
+	return result
+}
+
+func sortSubjectVersions(nodes []SubjectVersion) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Subject != nodes[j].Subject {
+			return nodes[i].Subject < nodes[j].Subject
+		}
+
+		return nodes[i].Version < nodes[j].Version
+	})
+}
+
+// GetReferenceGraph builds reference graph of subjects, or of all subjects
+// in schema registry if no subjects are provided
+//
+// Graph of subjects contains all their versions, together with subject
+// versions they reference and subject versions referencing them, directly
+// or transitively.
+func GetReferenceGraph(ctx context.Context, client Client, subjects ...string) (*ReferenceGraph, error) {
+	if len(subjects) == 0 {
+		return getRegistryReferenceGraph(ctx, client)
+	}
+
+	g := newReferenceGraph()
+	seen := map[SubjectVersion]bool{}
+	queue := []SubjectVersion{}
+
+	enqueue := func(node SubjectVersion) {
+		if !seen[node] {
+			seen[node] = true
+			queue = append(queue, node)
+		}
+	}
+
+	for _, subject := range subjects {
+		versions, err := client.GetSubjectVersions(ctx, subject)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reference graph of subject %s: %w", subject, err)
+		}
+
+		for _, version := range versions {
+			enqueue(SubjectVersion{Subject: subject, Version: version})
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		schema, err := client.GetSchemaByVersion(ctx, node.Subject, node.Version)
+		if errors.Is(err, ErrNotFound) {
+			// referenced subject version does not exist
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error getting reference graph node %s: %w", node, err)
+		}
+
+		g.addSchema(node, schema)
+		for _, ref := range schema.References {
+			enqueue(SubjectVersion{Subject: ref.Subject, Version: ref.Version})
+		}
+
+		ids, err := client.GetReferencedBy(ctx, node.Subject, node.Version)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reference graph node %s: %w", node, err)
+		}
+
+		for _, id := range ids {
+			versions, err := client.GetSchemaVersions(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("error getting reference graph node %s: %w", node, err)
+			}
+
+			for _, version := range versions {
+				enqueue(version)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// getRegistryReferenceGraph builds reference graph of all subjects
+func getRegistryReferenceGraph(ctx context.Context, client Client) (*ReferenceGraph, error) {
+	subjects, err := client.GetSubjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reference graph: %w", err)
+	}
+
+	g := newReferenceGraph()
+	for _, subject := range subjects {
+		versions, err := client.GetSubjectVersions(ctx, subject)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reference graph of subject %s: %w", subject, err)
+		}
+
+		for _, version := range versions {
+			schema, err := client.GetSchemaByVersion(ctx, subject, version)
+			if err != nil {
+				return nil, fmt.Errorf("error getting reference graph node %s/%d: %w", subject, version, err)
+			}
+
+			g.addSchema(SubjectVersion{Subject: subject, Version: version}, schema)
+		}
+	}
+
+	return g, nil
+}
@@ -0,0 +1,112 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/xtruder/go-kafka-protobuf/srclient/srtest"
+)
+
+func TestGetReferenceGraph(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewBaseClient(WithURL(server.URL))
+
+	create := func(subject string, refs ...*Schema) *Schema {
+		schema := &Schema{Subject: subject, Type: ProtobufSchemaType, Schema: `syntax = "proto3";`}
+		for _, ref := range refs {
+			schema.Schema += fmt.Sprintf(` import "%s";`, ref.Subject)
+			schema.References = append(schema.References, Reference{Name: ref.Subject, Subject: ref.Subject, Version: ref.Version})
+		}
+
+		created, err := c.CreateSchema(ctx, schema)
+		require.NoError(t, err)
+
+		return created
+	}
+
+	// common.proto <- types.proto <- topic-value
+	//              <-----------------/
+	common := create("common.proto")
+	types := create("types.proto", common)
+	value := create("topic-value", types, common)
+	other := create("other-value")
+
+	commonNode := SubjectVersion{"common.proto", 1}
+	typesNode := SubjectVersion{"types.proto", 1}
+	valueNode := SubjectVersion{"topic-value", 1}
+	otherNode := SubjectVersion{"other-value", 1}
+
+	g, err := GetReferenceGraph(ctx, c)
+	require.NoError(t, err)
+	require.Equal(t, []SubjectVersion{commonNode, otherNode, valueNode, typesNode}, g.Nodes())
+	require.Equal(t, value.ID, g.Schemas[valueNode].ID)
+	require.Equal(t, other.ID, g.Schemas[otherNode].ID)
+
+	require.Equal(t, []SubjectVersion{commonNode, typesNode}, g.References(valueNode))
+	require.Equal(t, []SubjectVersion{commonNode}, g.References(typesNode))
+	require.Empty(t, g.References(commonNode))
+
+	require.Equal(t, []SubjectVersion{valueNode, typesNode}, g.ReferencedBy(commonNode))
+	require.Equal(t, []SubjectVersion{valueNode}, g.ReferencedBy(typesNode))
+	require.Empty(t, g.ReferencedBy(valueNode))
+
+	require.Equal(t, []SubjectVersion{valueNode, typesNode}, g.Dependents(commonNode))
+	require.Empty(t, g.Dependents(otherNode))
+
+	// graph of subject contains referenced and referencing subject versions
+	g, err = GetReferenceGraph(ctx, c, "types.proto")
+	require.NoError(t, err)
+	require.Equal(t, []SubjectVersion{commonNode, valueNode, typesNode}, g.Nodes())
+	require.Equal(t, types.ID, g.Schemas[typesNode].ID)
+	require.Equal(t, common.ID, g.Schemas[commonNode].ID)
+	require.Equal(t, []SubjectVersion{valueNode, typesNode}, g.Dependents(commonNode))
+
+	// referenced subject versions can be deleted once dependents are deleted
+	_, err = c.DeleteSchemaByVersion(ctx, commonNode.Subject, commonNode.Version, false)
+	require.True(t, errors.Is(err, ErrReferenceExists))
+
+	for _, node := range []SubjectVersion{valueNode, typesNode, commonNode} {
+		_, err = c.DeleteSchemaByVersion(ctx, node.Subject, node.Version, false)
+		require.NoError(t, err)
+	}
+
+	_, err = GetReferenceGraph(ctx, c, "missing")
+	require.True(t, errors.Is(err, ErrSubjectNotFound))
+}
+
+func TestGetReferenceGraphSharedSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	c := NewMockClient(ctrl)
+
+	common := &Schema{ID: 1, Subject: "common.proto", Version: 1}
+	value := &Schema{ID: 2, Subject: "topic-value", References: []Reference{{Name: "common.proto", Subject: "common.proto", Version: 1}}}
+
+	commonNode := SubjectVersion{"common.proto", 1}
+	valueNodes := []SubjectVersion{{"topic-value", 1}, {"topic-value", 2}}
+
+	// same schema is registered as multiple versions of subject
+	c.EXPECT().GetSubjectVersions(ctx, "common.proto").Return([]int{1}, nil)
+	c.EXPECT().GetSchemaByVersion(ctx, "common.proto", 1).Return(common, nil)
+	c.EXPECT().GetReferencedBy(ctx, "common.proto", 1).Return([]int{value.ID}, nil)
+	c.EXPECT().GetSchemaVersions(ctx, value.ID).Return(valueNodes, nil)
+
+	for _, node := range valueNodes {
+		c.EXPECT().GetSchemaByVersion(ctx, node.Subject, node.Version).Return(value, nil)
+		c.EXPECT().GetReferencedBy(ctx, node.Subject, node.Version).Return([]int{}, nil)
+	}
+
+	// all subject versions of referencing schemas are in graph
+	g, err := GetReferenceGraph(ctx, c, "common.proto")
+	require.NoError(t, err)
+	require.Equal(t, valueNodes, g.ReferencedBy(commonNode))
+}
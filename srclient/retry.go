@@ -0,0 +1,111 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type retryPolicy struct {
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+}
+
+// backoff returns duration to wait before retry, honoring retry after
+// duration requested by server up to maximum backoff, so callers are not
+// blocked for arbitrary long time
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if p.maxBackoff > 0 && retryAfter > p.maxBackoff {
+		retryAfter = p.maxBackoff
+	}
+
+	backoff := float64(p.initialBackoff) * math.Pow(2, float64(attempt))
+	if p.maxBackoff > 0 && backoff > float64(p.maxBackoff) {
+		backoff = float64(p.maxBackoff)
+	}
+
+	if p.jitter > 0 {
+		backoff = backoff * (1 - p.jitter + 2*p.jitter*rand.Float64())
+	}
+
+	if retryAfter > time.Duration(backoff) {
+		return retryAfter
+	}
+
+	return time.Duration(backoff)
+}
+
+// isRetryable checks whether request failing with error can be retried
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return false
+	}
+
+	var registryErr *RegistryError
+	if errors.As(err, &registryErr) {
+		return registryErr.StatusCode >= 500 || registryErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// all other errors are connection errors
+	return true
+}
+
+// isRetryableDelete checks whether delete request failing with error can be
+// retried, which is only when schema registry did not process request, as
+// deleting already deleted subjects and versions fails
+func isRetryableDelete(ctx context.Context, err error) bool {
+	if !isRetryable(ctx, err) {
+		return false
+	}
+
+	var registryErr *RegistryError
+	if errors.As(err, &registryErr) {
+		return registryErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// request was not sent, if connection could not be made
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// parseRetryAfter parses Retry-After header, which is either number of
+// seconds or http date
+func parseRetryAfter(val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(val); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(val); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := retryPolicy{
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     time.Second,
+	}
+
+	require.Equal(t, 100*time.Millisecond, p.backoff(0, 0))
+	require.Equal(t, 200*time.Millisecond, p.backoff(1, 0))
+	require.Equal(t, 400*time.Millisecond, p.backoff(2, 0))
+	require.Equal(t, time.Second, p.backoff(10, 0))
+
+	// retry after is honored, if it is longer than backoff
+	require.Equal(t, 500*time.Millisecond, p.backoff(0, 500*time.Millisecond))
+	require.Equal(t, 200*time.Millisecond, p.backoff(1, 10*time.Millisecond))
+
+	// retry after is limited to maximum backoff
+	require.Equal(t, time.Second, p.backoff(0, time.Hour))
+
+	p.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := p.backoff(1, 0)
+		require.True(t, backoff >= 100*time.Millisecond && backoff <= 300*time.Millisecond)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	ctx := context.Background()
+
+	require.True(t, isRetryable(ctx, errors.New("connection refused")))
+	require.True(t, isRetryable(ctx, &RegistryError{StatusCode: http.StatusServiceUnavailable}))
+	require.True(t, isRetryable(ctx, &RegistryError{StatusCode: http.StatusTooManyRequests}))
+	require.False(t, isRetryable(ctx, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: 40401}))
+	require.False(t, isRetryable(ctx, &RegistryError{StatusCode: http.StatusConflict, ErrorCode: 409}))
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	require.False(t, isRetryable(ctx, errors.New("context canceled")))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	require.Equal(t, time.Duration(0), parseRetryAfter(""))
+	require.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	require.Equal(t, time.Duration(0), parseRetryAfter("invalid"))
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	retryAfter := parseRetryAfter(date)
+	require.True(t, retryAfter > 50*time.Second && retryAfter <= time.Minute)
+}
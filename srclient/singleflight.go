@@ -0,0 +1,163 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errCallPanicked = errors.New("coalesced call panicked")
+	errWaitTimeout  = errors.New("timeout waiting for coalesced call")
+)
+
+type flightCall struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// flightGroup coalesces concurrent calls with the same key into a single
+// call, whose result is shared between all callers
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+
+	// failed are errors of last background calls, that failed
+	failed map[string]error
+}
+
+// Do calls fn, unless call with the same key is already in flight, in which
+// case it waits for result of that call
+//
+// If call fails because context of caller that made the call is done, waiting
+// callers with live context make the call again, so they are not affected by
+// cancellation of another caller.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = map[string]*flightCall{}
+		}
+
+		if call, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+
+			if isContextError(call.err) && ctx.Err() == nil {
+				continue
+			}
+
+			return call.val, call.err
+		}
+
+		call := &flightCall{done: make(chan struct{}), err: errCallPanicked}
+		g.calls[key] = call
+		g.mu.Unlock()
+
+		g.do(ctx, key, call, fn)
+
+		return call.val, call.err
+	}
+}
+
+// Go calls fn in background with timeout, unless call with the same key is
+// already in flight, and returns call in flight
+func (g *flightGroup) Go(key string, timeout time.Duration, fn func(ctx context.Context) (interface{}, error)) *flightCall {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+
+	if call, ok := g.calls[key]; ok {
+		return call
+	}
+
+	call := &flightCall{done: make(chan struct{}), err: errCallPanicked}
+	g.calls[key] = call
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// errors are recorded before call is done, so they are seen by
+		// callers, that start next call
+		g.do(ctx, key, call, func(ctx context.Context) (interface{}, error) {
+			val, err := fn(ctx)
+			g.recordResult(key, err)
+
+			return val, err
+		})
+	}()
+
+	return call
+}
+
+// GoWait calls fn in background like Go and waits for result of call at most
+// wait duration, so callers are not blocked by hanging calls. If call is still
+// in flight, error of last background call with the same key is returned if
+// it failed, or errWaitTimeout otherwise.
+func (g *flightGroup) GoWait(ctx context.Context, key string, timeout time.Duration, wait time.Duration, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	call := g.Go(key, timeout, fn)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-timer.C:
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if err, ok := g.failed[key]; ok {
+			return nil, err
+		}
+
+		return nil, errWaitTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// recordResult records error of background call, or removes error of
+// previous call if call succeeded
+func (g *flightGroup) recordResult(key string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err == nil {
+		delete(g.failed, key)
+		return
+	}
+
+	if g.failed == nil {
+		g.failed = map[string]error{}
+	}
+
+	g.failed[key] = err
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, call *flightCall, fn func(ctx context.Context) (interface{}, error)) {
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.val, call.err = fn(ctx)
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
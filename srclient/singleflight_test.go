@@ -0,0 +1,147 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlightGroupCoalescesCalls(t *testing.T) {
+	var g flightGroup
+	var calls int32
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			val, err := g.Do(context.Background(), "key", fn)
+			require.NoError(t, err)
+			require.Equal(t, "value", val)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls)
+	require.Empty(t, g.calls)
+
+	// calls with different keys are not coalesced
+	release = make(chan struct{})
+	close(release)
+	_, err := g.Do(context.Background(), "key1", fn)
+	require.NoError(t, err)
+	_, err = g.Do(context.Background(), "key2", fn)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls)
+}
+
+func TestFlightGroupContextCancellation(t *testing.T) {
+	var g flightGroup
+	var calls int32
+
+	started := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		return "value", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errs := make(chan error)
+	go func() {
+		_, err := g.Do(ctx, "key", fn)
+		errs <- err
+	}()
+
+	<-started
+
+	// waiter with cancelled context returns immediately
+	waiterCtx, waiterCancel := context.WithCancel(context.Background())
+	waiterCancel()
+	_, err := g.Do(waiterCtx, "key", fn)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	// waiter retries call, when caller that made the call is cancelled
+	result := make(chan interface{})
+	go func() {
+		val, err := g.Do(context.Background(), "key", fn)
+		require.NoError(t, err)
+		result <- val
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	require.True(t, errors.Is(<-errs, context.Canceled))
+	require.Equal(t, "value", <-result)
+	require.Equal(t, int32(2), calls)
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+
+	require.Panics(t, func() {
+		g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			panic("failed")
+		})
+	})
+
+	val, err := g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+		return "value", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", val)
+}
+
+func TestFlightGroupGoWait(t *testing.T) {
+	var g flightGroup
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	// waiting callers are not blocked by calls in flight
+	_, err := g.GoWait(context.Background(), "key", 50*time.Millisecond, time.Millisecond, fn)
+	require.Equal(t, errWaitTimeout, err)
+
+	// background calls are canceled after timeout
+	_, err = g.GoWait(context.Background(), "key", 50*time.Millisecond, time.Second, fn)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Empty(t, g.calls)
+
+	// waiting callers get error of last failed call
+	_, err = g.GoWait(context.Background(), "key", 50*time.Millisecond, time.Millisecond, fn)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	_, err = g.GoWait(context.Background(), "key", 50*time.Millisecond, time.Second, fn)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	val, err := g.GoWait(context.Background(), "key", time.Second, time.Second, func(ctx context.Context) (interface{}, error) {
+		return "value", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", val)
+	require.Empty(t, g.failed)
+}
@@ -0,0 +1,178 @@
+package srclient
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/goburrow/cache"
+)
+
+type sizedEntry struct {
+	key   cache.Key
+	value cache.Value
+	size  int64
+}
+
+// sizedCache limits approximate size of cached keys and values in bytes,
+// evicting least recently used entries when size exceeds maximum size
+type sizedCache struct {
+	cache.Cache
+
+	maxBytes int64
+
+	// tombstone replaces evicted entries
+	tombstone cache.Value
+
+	// removalListener is called for entries evicted or removed by cache
+	removalListener cache.Func
+
+	mu      sync.Mutex
+	size    int64
+	entries map[cache.Key]*list.Element
+	order   *list.List
+}
+
+// newSizedCache creates cache limited to maxBytes, where cached values must
+// be comparable, so removals of replaced values can be ignored. Evicted
+// entries are replaced with tombstone, as cache invalidates entries
+// asynchronously and puts made shortly after invalidation can be lost.
+// Removal listener is called for entries evicted or removed by cache, if set.
+func newSizedCache(maxBytes int64, tombstone cache.Value, removalListener cache.Func, opts ...cache.Option) *sizedCache {
+	c := &sizedCache{
+		maxBytes:        maxBytes,
+		tombstone:       tombstone,
+		removalListener: removalListener,
+		entries:         map[cache.Key]*list.Element{},
+		order:           list.New(),
+	}
+
+	c.Cache = cache.New(append(opts, cache.WithRemovalListener(c.onRemoval))...)
+
+	return c
+}
+
+func (c *sizedCache) GetIfPresent(k cache.Key) (cache.Value, bool) {
+	v, exists := c.Cache.GetIfPresent(k)
+	if exists && v == c.tombstone {
+		return nil, false
+	}
+
+	if exists {
+		c.mu.Lock()
+		if el, ok := c.entries[k]; ok {
+			c.order.MoveToBack(el)
+		}
+		c.mu.Unlock()
+	}
+
+	return v, exists
+}
+
+func (c *sizedCache) Put(k cache.Key, v cache.Value) {
+	c.mu.Lock()
+
+	c.remove(k)
+	entry := &sizedEntry{key: k, value: v, size: approxSize(k) + approxSize(v)}
+	c.entries[k] = c.order.PushBack(entry)
+	c.size += entry.size
+
+	var evicted []*sizedEntry
+	for c.size > c.maxBytes && c.order.Len() > 1 {
+		entry := c.order.Front().Value.(*sizedEntry)
+		c.remove(entry.key)
+		evicted = append(evicted, entry)
+	}
+
+	c.mu.Unlock()
+
+	c.Cache.Put(k, v)
+
+	for _, entry := range evicted {
+		c.Cache.Put(entry.key, c.tombstone)
+
+		if c.removalListener != nil {
+			c.removalListener(entry.key, entry.value)
+		}
+	}
+}
+
+func (c *sizedCache) Invalidate(k cache.Key) {
+	c.mu.Lock()
+	c.remove(k)
+	c.mu.Unlock()
+
+	c.Cache.Invalidate(k)
+}
+
+func (c *sizedCache) InvalidateAll() {
+	c.mu.Lock()
+	c.entries = map[cache.Key]*list.Element{}
+	c.order.Init()
+	c.size = 0
+	c.mu.Unlock()
+
+	c.Cache.InvalidateAll()
+}
+
+// onRemoval removes entries removed by cache, like expired entries or
+// entries evicted by cache policy
+func (c *sizedCache) onRemoval(k cache.Key, v cache.Value) {
+	c.mu.Lock()
+	if el, ok := c.entries[k]; ok && el.Value.(*sizedEntry).value == v {
+		c.remove(k)
+	}
+	c.mu.Unlock()
+
+	if c.removalListener != nil {
+		c.removalListener(k, v)
+	}
+}
+
+// remove removes entry from size accounting, must be called with lock held
+func (c *sizedCache) remove(k cache.Key) {
+	if el, ok := c.entries[k]; ok {
+		c.size -= el.Value.(*sizedEntry).size
+		c.order.Remove(el)
+		delete(c.entries, k)
+	}
+}
+
+// Size returns approximate size of cached entries
+func (c *sizedCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// approxSize returns approximate size of cached key or value in bytes
+func approxSize(v interface{}) int64 {
+	const overhead = 16
+
+	switch v := v.(type) {
+	case *goburrowEntry:
+		return overhead + approxSize(v.value)
+	case *cacheEntry:
+		return overhead + approxSize(v.value)
+	case string:
+		return overhead + int64(len(v))
+	case CompatibilityLevel:
+		return overhead + int64(len(v))
+	case *Schema:
+		size := 4*overhead + int64(len(v.Subject)+len(v.Schema))
+		for _, ref := range v.References {
+			size += 2*overhead + int64(len(ref.Name)+len(ref.Subject))
+		}
+		return size
+	case []string:
+		size := int64(overhead)
+		for _, s := range v {
+			size += overhead + int64(len(s))
+		}
+		return size
+	case []int:
+		return overhead + 8*int64(len(v))
+	}
+
+	return overhead
+}
@@ -0,0 +1,68 @@
+package srclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goburrow/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizedCache(t *testing.T) {
+	entry := func(value string) *cacheEntry {
+		return &cacheEntry{value: value}
+	}
+
+	// each entry is approximately 100 bytes
+	value := string(make([]byte, 100-approxSize("k1")-approxSize(entry(""))))
+	evicted := []cache.Key{}
+	c := newSizedCache(300, entry(""), func(k cache.Key, v cache.Value) {
+		evicted = append(evicted, k)
+	})
+
+	c.Put("k1", entry(value))
+	c.Put("k2", entry(value))
+	c.Put("k3", entry(value))
+	require.EqualValues(t, 300, c.Size())
+
+	// recently used entries are kept
+	_, exists := c.GetIfPresent("k1")
+	require.True(t, exists)
+
+	c.Put("k4", entry(value))
+	require.EqualValues(t, 300, c.Size())
+
+	for key, cached := range map[string]bool{"k1": true, "k2": false, "k3": true, "k4": true} {
+		_, exists := c.GetIfPresent(key)
+		require.Equal(t, cached, exists, key)
+	}
+
+	// evicted entries are reported synchronously
+	require.Equal(t, []cache.Key{"k2"}, evicted)
+
+	// replaced entries are accounted once
+	c.Put("k4", entry(""))
+	require.EqualValues(t, 200+approxSize("k4")+approxSize(entry("")), c.Size())
+
+	c.Invalidate("k4")
+	require.EqualValues(t, 200, c.Size())
+
+	// entries bigger than maximum size evict all other entries
+	big := entry(strings.Repeat(value, 8))
+	c.Put("k5", big)
+	require.EqualValues(t, approxSize("k5")+approxSize(big), c.Size())
+
+	_, exists = c.GetIfPresent("k5")
+	require.True(t, exists)
+
+	_, exists = c.GetIfPresent("k1")
+	require.False(t, exists)
+
+	// evicted entries can be cached again
+	c.Put("k1", entry(value))
+	_, exists = c.GetIfPresent("k1")
+	require.True(t, exists)
+
+	c.InvalidateAll()
+	require.EqualValues(t, 0, c.Size())
+}
@@ -0,0 +1,1082 @@
+/*
+Package srtest implements in-memory fake schema registry server for tests
+
+Server implements subset of confluent schema registry REST API, that is used
+by srclient.BaseClient. Schema IDs and subject versions are assigned the same
+way as confluent schema registry does it, so identical schemas registered
+under different subjects share the same schema ID.
+*/
+package srtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// schema registry error codes
+const (
+	errCodeSubjectNotFound       = 40401
+	errCodeVersionNotFound       = 40402
+	errCodeSchemaNotFound        = 40403
+	errCodeSubjectSoftDeleted    = 40404
+	errCodeSubjectNotSoftDeleted = 40405
+	errCodeVersionNotSoftDeleted = 40407
+	errCodeSubjectConfigNotFound = 40408
+	errCodeSubjectModeNotFound   = 40409
+	errCodeInvalidSchema         = 42201
+	errCodeInvalidVersion        = 42202
+	errCodeInvalidCompatibility  = 42203
+	errCodeInvalidMode           = 42204
+	errCodeOperationNotPermitted = 42205
+	errCodeReferenceExists       = 42206
+	errCodeIDDoesNotMatch        = 42207
+)
+
+// defaultCompatibility is default global compatibility level
+const defaultCompatibility = "BACKWARD"
+
+var compatibilityLevels = map[string]bool{
+	"BACKWARD":            true,
+	"BACKWARD_TRANSITIVE": true,
+	"FORWARD":             true,
+	"FORWARD_TRANSITIVE":  true,
+	"FULL":                true,
+	"FULL_TRANSITIVE":     true,
+	"NONE":                true,
+}
+
+const (
+	modeReadWrite = "READWRITE"
+	modeReadOnly  = "READONLY"
+	modeImport    = "IMPORT"
+)
+
+var modes = map[string]bool{
+	modeReadWrite: true,
+	modeReadOnly:  true,
+	modeImport:    true,
+}
+
+// Reference defines schema reference
+type Reference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+type schemaRecord struct {
+	Schema     string
+	SchemaType string
+	References []Reference
+}
+
+// key returns unique key of schema record used for schema deduplication
+func (s *schemaRecord) key() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\x00%s\x00", s.SchemaType, s.Schema)
+	for _, ref := range s.References {
+		fmt.Fprintf(b, "%s\x00%s\x00%d\x00", ref.Name, ref.Subject, ref.Version)
+	}
+
+	return b.String()
+}
+
+type subjectVersion struct {
+	Version int
+	ID      int
+	Deleted bool
+}
+
+// Server is in-memory fake schema registry server
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	lastID   int
+	schemas  map[int]*schemaRecord
+	ids      map[string]int
+	subjects map[string][]*subjectVersion
+
+	compatibility        string
+	subjectCompatibility map[string]string
+
+	mode         string
+	subjectModes map[string]string
+
+	checker CompatibilityChecker
+}
+
+// CompatibilityChecker checks compatibility of schema against previous schema
+// of subject and returns incompatibility messages, or no messages if schema
+// is compatible
+type CompatibilityChecker func(subject string, schema string, previous string) []string
+
+// NewServer creates and starts a new fake schema registry server
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer creates a new fake schema registry server, without
+// starting it
+func NewUnstartedServer() *Server {
+	s := &Server{}
+	s.reset()
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Reset removes all registered schemas and subjects
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+}
+
+// SetCompatibilityChecker sets checker used by compatibility checks, as fake
+// server does not parse schemas and reports all schemas as compatible by
+// default
+func (s *Server) SetCompatibilityChecker(checker CompatibilityChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checker = checker
+}
+
+func (s *Server) reset() {
+	s.lastID = 0
+	s.schemas = map[int]*schemaRecord{}
+	s.ids = map[string]int{}
+	s.subjects = map[string][]*subjectVersion{}
+	s.compatibility = defaultCompatibility
+	s.subjectCompatibility = map[string]string{}
+	s.mode = modeReadWrite
+	s.subjectModes = map[string]string{}
+}
+
+type httpError struct {
+	status  int
+	code    int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func newHTTPError(status int, code int, format string, args ...interface{}) *httpError {
+	return &httpError{status: status, code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func errSubjectNotFound(subject string) *httpError {
+	return newHTTPError(http.StatusNotFound, errCodeSubjectNotFound, "Subject '%s' not found.", subject)
+}
+
+func errVersionNotFound(version int) *httpError {
+	return newHTTPError(http.StatusNotFound, errCodeVersionNotFound, "Version %d not found.", version)
+}
+
+func errSchemaNotFound(id int) *httpError {
+	return newHTTPError(http.StatusNotFound, errCodeSchemaNotFound, "Schema %d not found", id)
+}
+
+func errInvalidVersion(version string) *httpError {
+	return newHTTPError(http.StatusUnprocessableEntity, errCodeInvalidVersion,
+		"The specified version '%s' is not a valid version id. Allowed values are between [1, 2^31-1] and the string \"latest\"", version)
+}
+
+func errInvalidSchema(format string, args ...interface{}) *httpError {
+	return newHTTPError(http.StatusUnprocessableEntity, errCodeInvalidSchema, format, args...)
+}
+
+func errOperationNotPermitted(format string, args ...interface{}) *httpError {
+	return newHTTPError(http.StatusUnprocessableEntity, errCodeOperationNotPermitted, format, args...)
+}
+
+func errReferenceExists(subject string, version int, ids []int) *httpError {
+	return newHTTPError(http.StatusUnprocessableEntity, errCodeReferenceExists,
+		"One or more references exist to the schema {subject=%s,version=%d}: %v", subject, version, ids)
+}
+
+func errNotFound() *httpError {
+	return newHTTPError(http.StatusNotFound, http.StatusNotFound, "HTTP 404 Not Found")
+}
+
+type handlerFunc func(r *http.Request, params []string) (interface{}, error)
+
+type route struct {
+	method  string
+	pattern []string
+	handler handlerFunc
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{"GET", []string{"subjects"}, s.getSubjects},
+		{"GET", []string{"subjects", "*", "versions"}, s.getSubjectVersions},
+		{"POST", []string{"subjects", "*", "versions"}, s.createSchema},
+		{"GET", []string{"subjects", "*", "versions", "*"}, s.getSchemaByVersion},
+		{"GET", []string{"subjects", "*", "versions", "*", "referencedby"}, s.getReferencedBy},
+		{"POST", []string{"subjects", "*"}, s.lookupSchema},
+		{"DELETE", []string{"subjects", "*"}, s.deleteSubject},
+		{"DELETE", []string{"subjects", "*", "versions", "*"}, s.deleteSchemaByVersion},
+		{"GET", []string{"schemas", "ids", "*"}, s.getSchemaByID},
+		{"GET", []string{"schemas", "ids", "*", "versions"}, s.getSchemaSubjectVersions},
+		{"POST", []string{"compatibility", "subjects", "*", "versions"}, s.checkCompatibility},
+		{"POST", []string{"compatibility", "subjects", "*", "versions", "*"}, s.checkCompatibility},
+		{"GET", []string{"config"}, s.getConfig},
+		{"PUT", []string{"config"}, s.setConfig},
+		{"DELETE", []string{"config"}, s.deleteConfig},
+		{"GET", []string{"config", "*"}, s.getSubjectConfig},
+		{"PUT", []string{"config", "*"}, s.setSubjectConfig},
+		{"DELETE", []string{"config", "*"}, s.deleteSubjectConfig},
+		{"GET", []string{"mode"}, s.getMode},
+		{"PUT", []string{"mode"}, s.setMode},
+		{"GET", []string{"mode", "*"}, s.getSubjectMode},
+		{"PUT", []string{"mode", "*"}, s.setSubjectMode},
+		{"DELETE", []string{"mode", "*"}, s.deleteSubjectMode},
+	}
+}
+
+// matchRoute matches path segments against route pattern and returns
+// path parameters matched by wildcards
+func matchRoute(pattern []string, segments []string) ([]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	params := []string{}
+	for i, p := range pattern {
+		if p == "*" {
+			params = append(params, segments[i])
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// path segments are split on escaped path, so subjects containing
+	// slashes are correctly handled
+	segments := []string{}
+	for _, segment := range strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/") {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			writeError(w, errNotFound())
+			return
+		}
+
+		segments = append(segments, unescaped)
+	}
+
+	for _, route := range s.routes() {
+		if route.method != r.Method {
+			continue
+		}
+
+		params, ok := matchRoute(route.pattern, segments)
+		if !ok {
+			continue
+		}
+
+		s.mu.Lock()
+		resp, err := route.handler(r, params)
+		s.mu.Unlock()
+
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	writeError(w, errNotFound())
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	httpErr, ok := err.(*httpError)
+	if !ok {
+		httpErr = newHTTPError(http.StatusInternalServerError, 50001, "%s", err.Error())
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(httpErr.status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error_code": httpErr.code,
+		"message":    httpErr.message,
+	})
+}
+
+func queryBool(r *http.Request, name string) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get(name))
+	return v
+}
+
+// parseVersion parses version path param, returning -1 for latest version
+func parseVersion(version string) (int, error) {
+	if version == "latest" || version == "-1" {
+		return -1, nil
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return 0, errInvalidVersion(version)
+	}
+
+	return v, nil
+}
+
+// activeVersions returns subject versions, that are not soft deleted
+func (s *Server) activeVersions(subject string) []*subjectVersion {
+	versions := []*subjectVersion{}
+	for _, v := range s.subjects[subject] {
+		if !v.Deleted {
+			versions = append(versions, v)
+		}
+	}
+
+	return versions
+}
+
+// findVersion finds subject version, including soft deleted versions if
+// deleted is set
+func (s *Server) findVersion(subject string, version string, deleted bool) (*subjectVersion, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := s.activeVersions(subject)
+	if deleted {
+		versions = s.subjects[subject]
+	}
+
+	if len(versions) == 0 {
+		return nil, errSubjectNotFound(subject)
+	}
+
+	if v == -1 {
+		return versions[len(versions)-1], nil
+	}
+
+	for _, sv := range versions {
+		if sv.Version == v {
+			return sv, nil
+		}
+	}
+
+	return nil, errVersionNotFound(v)
+}
+
+// subjectMode returns mode of subject, defaulting to global mode
+func (s *Server) subjectMode(subject string) string {
+	if mode, ok := s.subjectModes[subject]; ok {
+		return mode
+	}
+
+	return s.mode
+}
+
+// checkWritable checks whether subject can be modified
+func (s *Server) checkWritable(subject string) error {
+	if s.subjectMode(subject) == modeReadOnly {
+		return errOperationNotPermitted("Subject %s is in read-only mode", subject)
+	}
+
+	return nil
+}
+
+// referencedBy returns sorted ids of schemas of active subject versions,
+// that reference subject version
+func (s *Server) referencedBy(subject string, version int) []int {
+	ids := []int{}
+	seen := map[int]bool{}
+	for name := range s.subjects {
+		for _, v := range s.activeVersions(name) {
+			if seen[v.ID] {
+				continue
+			}
+
+			for _, ref := range s.schemas[v.ID].References {
+				if ref.Subject == subject && ref.Version == version {
+					seen[v.ID] = true
+					ids = append(ids, v.ID)
+					break
+				}
+			}
+		}
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+// removeUnusedSchemas removes schemas that are not referenced by any subject
+// version anymore
+func (s *Server) removeUnusedSchemas() {
+	used := map[int]bool{}
+	for _, versions := range s.subjects {
+		for _, v := range versions {
+			used[v.ID] = true
+		}
+	}
+
+	for id, schema := range s.schemas {
+		if !used[id] {
+			delete(s.ids, schema.key())
+			delete(s.schemas, id)
+		}
+	}
+}
+
+func (s *Server) getSubjects(r *http.Request, params []string) (interface{}, error) {
+	deleted := queryBool(r, "deleted")
+
+	subjects := []string{}
+	for subject, versions := range s.subjects {
+		if deleted && len(versions) > 0 || len(s.activeVersions(subject)) > 0 {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	sort.Strings(subjects)
+
+	return subjects, nil
+}
+
+func (s *Server) getSubjectVersions(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	versions := s.activeVersions(subject)
+	if queryBool(r, "deleted") {
+		versions = s.subjects[subject]
+	}
+
+	if len(versions) == 0 {
+		return nil, errSubjectNotFound(subject)
+	}
+
+	result := []int{}
+	for _, v := range versions {
+		result = append(result, v.Version)
+	}
+
+	return result, nil
+}
+
+type schemaRequest struct {
+	ID         int         `json:"id"`
+	Version    int         `json:"version"`
+	Schema     string      `json:"schema"`
+	SchemaType string      `json:"schemaType"`
+	References []Reference `json:"references"`
+}
+
+func decodeSchemaRequest(r *http.Request) (*schemaRequest, error) {
+	req := &schemaRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, newHTTPError(http.StatusBadRequest, http.StatusBadRequest, "Unrecognized request: %s", err)
+	}
+
+	if req.Schema == "" {
+		return nil, errInvalidSchema("Empty schema")
+	}
+
+	if req.SchemaType == "" {
+		req.SchemaType = "AVRO"
+	}
+
+	return req, nil
+}
+
+func (s *Server) createSchema(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	if err := s.checkWritable(subject); err != nil {
+		return nil, err
+	}
+
+	req, err := decodeSchemaRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// all references must point to existing subject versions
+	for _, ref := range req.References {
+		if _, err := s.findVersion(ref.Subject, strconv.Itoa(ref.Version), false); err != nil {
+			return nil, errInvalidSchema("Invalid schema reference %s: %s", ref.Name, err)
+		}
+	}
+
+	record := &schemaRecord{
+		Schema:     req.Schema,
+		SchemaType: req.SchemaType,
+		References: req.References,
+	}
+
+	// if schema is already registered under subject, return existing id
+	if id, exists := s.ids[record.key()]; exists {
+		for _, v := range s.activeVersions(subject) {
+			if v.ID != id {
+				continue
+			}
+
+			if req.ID > 0 && req.ID != id {
+				return nil, newHTTPError(http.StatusUnprocessableEntity, errCodeIDDoesNotMatch,
+					"Schema already registered with id %d instead of input id %d", id, req.ID)
+			}
+
+			return map[string]int{"id": id}, nil
+		}
+	}
+
+	if req.ID > 0 || req.Version > 0 {
+		if s.subjectMode(subject) != modeImport {
+			return nil, errOperationNotPermitted("Subject %s is not in import mode", subject)
+		}
+
+		return s.importSchema(subject, req.ID, req.Version, record)
+	}
+
+	// deduplicate schemas across all subjects
+	id, exists := s.ids[record.key()]
+	if !exists {
+		s.lastID++
+		id = s.lastID
+		s.ids[record.key()] = id
+		s.schemas[id] = record
+	}
+
+	version := 1
+	if versions := s.subjects[subject]; len(versions) > 0 {
+		version = versions[len(versions)-1].Version + 1
+	}
+
+	s.subjects[subject] = append(s.subjects[subject], &subjectVersion{
+		Version: version,
+		ID:      id,
+	})
+
+	return map[string]int{"id": id}, nil
+}
+
+// importSchema registers schema with explicit schema id and version
+func (s *Server) importSchema(subject string, id int, version int, record *schemaRecord) (interface{}, error) {
+	if id == 0 {
+		var exists bool
+		if id, exists = s.ids[record.key()]; !exists {
+			s.lastID++
+			id = s.lastID
+		}
+	}
+
+	if existing, ok := s.schemas[id]; ok && existing.key() != record.key() {
+		return nil, errOperationNotPermitted("Overwrite new schema with id %d is not permitted.", id)
+	}
+
+	if _, exists := s.ids[record.key()]; !exists {
+		s.ids[record.key()] = id
+	}
+
+	s.schemas[id] = record
+	if id > s.lastID {
+		s.lastID = id
+	}
+
+	versions := s.subjects[subject]
+	if version == 0 {
+		version = 1
+		if len(versions) > 0 {
+			version = versions[len(versions)-1].Version + 1
+		}
+	}
+
+	for _, v := range versions {
+		if v.Version == version {
+			if v.ID != id {
+				return nil, errOperationNotPermitted("Overwrite new schema with version %d is not permitted.", version)
+			}
+
+			return map[string]int{"id": id}, nil
+		}
+	}
+
+	// keep versions sorted
+	versions = append(versions, &subjectVersion{Version: version, ID: id})
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	s.subjects[subject] = versions
+
+	return map[string]int{"id": id}, nil
+}
+
+type schemaResponse struct {
+	Subject    string      `json:"subject,omitempty"`
+	Version    int         `json:"version,omitempty"`
+	ID         int         `json:"id,omitempty"`
+	Schema     string      `json:"schema"`
+	SchemaType string      `json:"schemaType,omitempty"`
+	References []Reference `json:"references,omitempty"`
+}
+
+func newSchemaResponse(record *schemaRecord) *schemaResponse {
+	resp := &schemaResponse{
+		Schema:     record.Schema,
+		References: record.References,
+	}
+
+	// schema registry omits schema type for avro schemas
+	if record.SchemaType != "AVRO" {
+		resp.SchemaType = record.SchemaType
+	}
+
+	return resp
+}
+
+func (s *Server) getSchemaByVersion(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	v, err := s.findVersion(subject, params[1], queryBool(r, "deleted"))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := newSchemaResponse(s.schemas[v.ID])
+	resp.Subject = subject
+	resp.Version = v.Version
+	resp.ID = v.ID
+
+	return resp, nil
+}
+
+func (s *Server) lookupSchema(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	req, err := decodeSchemaRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := s.activeVersions(subject)
+	if queryBool(r, "deleted") {
+		versions = s.subjects[subject]
+	}
+
+	if len(versions) == 0 {
+		return nil, errSubjectNotFound(subject)
+	}
+
+	record := &schemaRecord{
+		Schema:     req.Schema,
+		SchemaType: req.SchemaType,
+		References: req.References,
+	}
+
+	if id, exists := s.ids[record.key()]; exists {
+		for _, v := range versions {
+			if v.ID == id {
+				resp := newSchemaResponse(record)
+				resp.Subject = subject
+				resp.Version = v.Version
+				resp.ID = id
+
+				return resp, nil
+			}
+		}
+	}
+
+	return nil, newHTTPError(http.StatusNotFound, errCodeSchemaNotFound, "Schema not found")
+}
+
+func (s *Server) deleteSubject(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+	permanent := queryBool(r, "permanent")
+
+	if err := s.checkWritable(subject); err != nil {
+		return nil, err
+	}
+
+	versions := s.subjects[subject]
+	if len(versions) == 0 {
+		return nil, errSubjectNotFound(subject)
+	}
+
+	active := s.activeVersions(subject)
+	if permanent && len(active) > 0 {
+		return nil, newHTTPError(http.StatusNotFound, errCodeSubjectNotSoftDeleted,
+			"Subject '%s' was not deleted first before being permanently deleted", subject)
+	}
+
+	if !permanent && len(active) == 0 {
+		return nil, newHTTPError(http.StatusNotFound, errCodeSubjectSoftDeleted,
+			"Subject '%s' was soft deleted.Set permanent=true to delete permanently", subject)
+	}
+
+	// referenced versions can not be deleted
+	if !permanent {
+		for _, v := range active {
+			if ids := s.referencedBy(subject, v.Version); len(ids) > 0 {
+				return nil, errReferenceExists(subject, v.Version, ids)
+			}
+		}
+	}
+
+	result := []int{}
+	for _, v := range versions {
+		if permanent || !v.Deleted {
+			result = append(result, v.Version)
+		}
+
+		v.Deleted = true
+	}
+
+	if permanent {
+		delete(s.subjects, subject)
+		delete(s.subjectCompatibility, subject)
+		delete(s.subjectModes, subject)
+		s.removeUnusedSchemas()
+	}
+
+	return result, nil
+}
+
+func (s *Server) deleteSchemaByVersion(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+	permanent := queryBool(r, "permanent")
+
+	if err := s.checkWritable(subject); err != nil {
+		return nil, err
+	}
+
+	v, err := s.findVersion(subject, params[1], permanent)
+	if err != nil {
+		return nil, err
+	}
+
+	if permanent {
+		if !v.Deleted {
+			return nil, newHTTPError(http.StatusNotFound, errCodeVersionNotSoftDeleted,
+				"Subject '%s' Version %d was not deleted first before being permanently deleted", subject, v.Version)
+		}
+
+		versions := []*subjectVersion{}
+		for _, sv := range s.subjects[subject] {
+			if sv != v {
+				versions = append(versions, sv)
+			}
+		}
+
+		if len(versions) > 0 {
+			s.subjects[subject] = versions
+		} else {
+			delete(s.subjects, subject)
+		}
+
+		s.removeUnusedSchemas()
+
+		return v.Version, nil
+	}
+
+	// referenced versions can not be deleted
+	if ids := s.referencedBy(subject, v.Version); len(ids) > 0 {
+		return nil, errReferenceExists(subject, v.Version, ids)
+	}
+
+	v.Deleted = true
+
+	return v.Version, nil
+}
+
+func (s *Server) getReferencedBy(r *http.Request, params []string) (interface{}, error) {
+	v, err := s.findVersion(params[0], params[1], false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.referencedBy(params[0], v.Version), nil
+}
+
+func (s *Server) getSchemaByID(r *http.Request, params []string) (interface{}, error) {
+	id, err := strconv.Atoi(params[0])
+	if err != nil {
+		return nil, errNotFound()
+	}
+
+	record, ok := s.schemas[id]
+	if !ok {
+		return nil, errSchemaNotFound(id)
+	}
+
+	return newSchemaResponse(record), nil
+}
+
+func (s *Server) getSchemaSubjectVersions(r *http.Request, params []string) (interface{}, error) {
+	id, err := strconv.Atoi(params[0])
+	if err != nil {
+		return nil, errNotFound()
+	}
+
+	if _, ok := s.schemas[id]; !ok {
+		return nil, errSchemaNotFound(id)
+	}
+
+	type subjectVersionResponse struct {
+		Subject string `json:"subject"`
+		Version int    `json:"version"`
+	}
+
+	subjects := []string{}
+	for subject := range s.subjects {
+		subjects = append(subjects, subject)
+	}
+
+	sort.Strings(subjects)
+
+	result := []subjectVersionResponse{}
+	for _, subject := range subjects {
+		for _, v := range s.activeVersions(subject) {
+			if v.ID == id {
+				result = append(result, subjectVersionResponse{subject, v.Version})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// checkCompatibility checks compatibility of schema against subject version,
+// or against versions checked by subject compatibility level if version is
+// not provided
+func (s *Server) checkCompatibility(r *http.Request, params []string) (interface{}, error) {
+	req, err := decodeSchemaRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := params[0]
+
+	level := s.compatibility
+	if subjectLevel, ok := s.subjectCompatibility[subject]; ok {
+		level = subjectLevel
+	}
+
+	var versions []*subjectVersion
+	if len(params) > 1 {
+		v, err := s.findVersion(subject, params[1], false)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = []*subjectVersion{v}
+	} else {
+		versions = s.activeVersions(subject)
+		if !strings.HasSuffix(level, "_TRANSITIVE") && len(versions) > 0 {
+			versions = versions[len(versions)-1:]
+		}
+	}
+
+	messages := []string{}
+	if s.checker != nil && level != "NONE" {
+		for i := len(versions) - 1; i >= 0; i-- {
+			previous := s.schemas[versions[i].ID].Schema
+
+			msgs := s.checker(subject, req.Schema, previous)
+			if len(msgs) == 0 {
+				continue
+			}
+
+			messages = append(messages, msgs...)
+			messages = append(messages,
+				fmt.Sprintf("{oldSchemaVersion: %d}", versions[i].Version),
+				fmt.Sprintf("{oldSchema: '%s'}", previous),
+				fmt.Sprintf("{compatibility: '%s'}", level))
+		}
+	}
+
+	resp := map[string]interface{}{"is_compatible": len(messages) == 0}
+	if queryBool(r, "verbose") {
+		resp["messages"] = messages
+	}
+
+	return resp, nil
+}
+
+func decodeConfigRequest(r *http.Request) (string, error) {
+	var req struct {
+		Compatibility string `json:"compatibility"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return "", newHTTPError(http.StatusBadRequest, http.StatusBadRequest, "Unrecognized request: %s", err)
+	}
+
+	if !compatibilityLevels[req.Compatibility] {
+		return "", newHTTPError(http.StatusUnprocessableEntity, errCodeInvalidCompatibility,
+			"Invalid compatibility level. Valid values are none, backward, forward, full, backward_transitive, forward_transitive, and full_transitive")
+	}
+
+	return req.Compatibility, nil
+}
+
+func (s *Server) getConfig(r *http.Request, params []string) (interface{}, error) {
+	return map[string]string{"compatibilityLevel": s.compatibility}, nil
+}
+
+func (s *Server) setConfig(r *http.Request, params []string) (interface{}, error) {
+	level, err := decodeConfigRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.compatibility = level
+
+	return map[string]string{"compatibility": level}, nil
+}
+
+func (s *Server) deleteConfig(r *http.Request, params []string) (interface{}, error) {
+	prev := s.compatibility
+	s.compatibility = defaultCompatibility
+
+	return map[string]string{"compatibilityLevel": prev}, nil
+}
+
+func (s *Server) getSubjectConfig(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	level, ok := s.subjectCompatibility[subject]
+	if !ok {
+		if !queryBool(r, "defaultToGlobal") {
+			return nil, newHTTPError(http.StatusNotFound, errCodeSubjectConfigNotFound,
+				"Subject '%s' does not have subject-level compatibility configured", subject)
+		}
+
+		level = s.compatibility
+	}
+
+	return map[string]string{"compatibilityLevel": level}, nil
+}
+
+func (s *Server) setSubjectConfig(r *http.Request, params []string) (interface{}, error) {
+	level, err := decodeConfigRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.subjectCompatibility[params[0]] = level
+
+	return map[string]string{"compatibility": level}, nil
+}
+
+func (s *Server) deleteSubjectConfig(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	prev, ok := s.subjectCompatibility[subject]
+	if !ok {
+		return nil, errSubjectNotFound(subject)
+	}
+
+	delete(s.subjectCompatibility, subject)
+
+	return map[string]string{"compatibilityLevel": prev}, nil
+}
+
+func decodeModeRequest(r *http.Request) (string, error) {
+	var req struct {
+		Mode string `json:"mode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return "", newHTTPError(http.StatusBadRequest, http.StatusBadRequest, "Unrecognized request: %s", err)
+	}
+
+	if !modes[req.Mode] {
+		return "", newHTTPError(http.StatusUnprocessableEntity, errCodeInvalidMode,
+			"Invalid mode. Valid values are READWRITE, READONLY and IMPORT.")
+	}
+
+	return req.Mode, nil
+}
+
+func (s *Server) getMode(r *http.Request, params []string) (interface{}, error) {
+	return map[string]string{"mode": s.mode}, nil
+}
+
+func (s *Server) setMode(r *http.Request, params []string) (interface{}, error) {
+	mode, err := decodeModeRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == modeImport && len(s.subjects) > 0 && !queryBool(r, "force") {
+		return nil, errOperationNotPermitted("Cannot import since found existing subjects")
+	}
+
+	s.mode = mode
+
+	return map[string]string{"mode": mode}, nil
+}
+
+func (s *Server) getSubjectMode(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	mode, ok := s.subjectModes[subject]
+	if !ok {
+		if !queryBool(r, "defaultToGlobal") {
+			return nil, newHTTPError(http.StatusNotFound, errCodeSubjectModeNotFound,
+				"Subject '%s' does not have subject-level mode configured", subject)
+		}
+
+		mode = s.mode
+	}
+
+	return map[string]string{"mode": mode}, nil
+}
+
+func (s *Server) setSubjectMode(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	mode, err := decodeModeRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == modeImport && len(s.subjects[subject]) > 0 && !queryBool(r, "force") {
+		return nil, errOperationNotPermitted("Cannot import since found existing subjects")
+	}
+
+	s.subjectModes[subject] = mode
+
+	return map[string]string{"mode": mode}, nil
+}
+
+func (s *Server) deleteSubjectMode(r *http.Request, params []string) (interface{}, error) {
+	subject := params[0]
+
+	prev, ok := s.subjectModes[subject]
+	if !ok {
+		return nil, errSubjectNotFound(subject)
+	}
+
+	delete(s.subjectModes, subject)
+
+	return map[string]string{"mode": prev}, nil
+}
@@ -0,0 +1,149 @@
+package srtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xtruder/go-kafka-protobuf/srclient"
+	"github.com/xtruder/go-kafka-protobuf/srclient/srtest"
+)
+
+func newSchema(subject string, schema string) *srclient.Schema {
+	return &srclient.Schema{
+		Subject: subject,
+		Type:    srclient.ProtobufSchemaType,
+		Schema:  schema,
+	}
+}
+
+func TestServerSchemaIDs(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	schema1, err := client.CreateSchema(ctx, newSchema("sub1", `syntax = "proto3";`))
+	require.NoError(t, err)
+	require.Equal(t, 1, schema1.ID)
+	require.Equal(t, 1, schema1.Version)
+
+	// same schema under same subject is not registered again
+	schema, err := client.CreateSchema(ctx, newSchema("sub1", `syntax = "proto3";`))
+	require.NoError(t, err)
+	require.Equal(t, schema1.ID, schema.ID)
+	require.Equal(t, schema1.Version, schema.Version)
+
+	// same schema under different subject shares schema id
+	schema2, err := client.CreateSchema(ctx, newSchema("sub2", `syntax = "proto3";`))
+	require.NoError(t, err)
+	require.Equal(t, schema1.ID, schema2.ID)
+	require.Equal(t, 1, schema2.Version)
+
+	// different schema gets new id and version
+	schema3, err := client.CreateSchema(ctx, newSchema("sub1", `syntax = "proto2";`))
+	require.NoError(t, err)
+	require.Equal(t, 2, schema3.ID)
+	require.Equal(t, 2, schema3.Version)
+
+	versions, err := client.GetSchemaSubjectVersions(ctx, schema1.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"sub1": 1, "sub2": 1}, versions)
+
+	subjectVersions, err := client.GetSchemaVersions(ctx, schema1.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []srclient.SubjectVersion{{Subject: "sub1", Version: 1}, {Subject: "sub2", Version: 1}}, subjectVersions)
+}
+
+func TestServerReferences(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	dep, err := client.CreateSchema(ctx, newSchema("dir/dep.proto", `syntax = "proto3";`))
+	require.NoError(t, err)
+
+	schema := newSchema("sub", `syntax = "proto3"; import "dir/dep.proto";`)
+	schema.References = []srclient.Reference{{Name: "dir/dep.proto", Subject: dep.Subject, Version: dep.Version}}
+
+	created, err := client.CreateSchema(ctx, schema)
+	require.NoError(t, err)
+
+	result, err := client.GetSchemaByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, schema.References, result.References)
+
+	ids, err := client.GetReferencedBy(ctx, dep.Subject, dep.Version)
+	require.NoError(t, err)
+	require.Equal(t, []int{created.ID}, ids)
+
+	// referenced versions can not be deleted
+	_, err = client.DeleteSchemaByVersion(ctx, dep.Subject, dep.Version, false)
+	require.True(t, errors.Is(err, srclient.ErrReferenceExists))
+
+	_, err = client.DeleteSubject(ctx, dep.Subject, false)
+	require.True(t, errors.Is(err, srclient.ErrReferenceExists))
+
+	// references of deleted versions are ignored
+	_, err = client.DeleteSubject(ctx, "sub", false)
+	require.NoError(t, err)
+
+	ids, err = client.GetReferencedBy(ctx, dep.Subject, dep.Version)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	_, err = client.DeleteSubject(ctx, dep.Subject, false)
+	require.NoError(t, err)
+
+	// references to missing subjects are rejected
+	schema.References = []srclient.Reference{{Name: "missing.proto", Subject: "missing.proto", Version: 1}}
+	_, err = client.CreateSchema(ctx, schema)
+	require.Error(t, err)
+}
+
+func TestServerDelete(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	client := srclient.NewClient(srclient.WithURL(server.URL))
+
+	schema1, err := client.CreateSchema(ctx, newSchema("sub", `syntax = "proto3";`))
+	require.NoError(t, err)
+
+	schema2, err := client.CreateSchema(ctx, newSchema("sub", `syntax = "proto2";`))
+	require.NoError(t, err)
+
+	_, err = client.DeleteSchemaByVersion(ctx, "sub", schema2.Version, false)
+	require.NoError(t, err)
+
+	latest, err := client.GetLatestSchema(ctx, "sub")
+	require.NoError(t, err)
+	require.Equal(t, schema1.Version, latest.Version)
+
+	// soft deleted versions are not reused
+	schema3, err := client.CreateSchema(ctx, newSchema("sub", `syntax = "proto2";`))
+	require.NoError(t, err)
+	require.Equal(t, 3, schema3.Version)
+	require.Equal(t, schema2.ID, schema3.ID)
+
+	versions, err := client.DeleteSubject(ctx, "sub", true)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 3}, versions)
+
+	_, err = client.GetSubjectVersions(ctx, "sub")
+	require.True(t, errors.Is(err, srclient.ErrNotFound))
+
+	_, err = client.GetSchemaByID(ctx, schema1.ID)
+	require.True(t, errors.Is(err, srclient.ErrNotFound))
+
+	server.Reset()
+
+	subjects, err := client.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Empty(t, subjects)
+}
@@ -0,0 +1,299 @@
+package srclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+type tlsOptions struct {
+	insecure bool
+
+	caFile string
+	caPEM  []byte
+
+	certFile string
+	keyFile  string
+	certPEM  []byte
+	keyPEM   []byte
+
+	reloadInterval time.Duration
+}
+
+// WithInsecure option sets insecure skip verify
+func WithInsecure(insecure ...bool) BaseClientOption {
+	return func(c *BaseClient) {
+		c.tlsOptions().insecure = enableOpt(insecure)
+	}
+}
+
+// WithCAFile option sets file with PEM encoded CA certificates used to
+// verify schema registry certificate
+func WithCAFile(caFile string) BaseClientOption {
+	return func(c *BaseClient) {
+		c.tlsOptions().caFile = caFile
+	}
+}
+
+// WithCAPEM option sets PEM encoded CA certificates used to verify schema
+// registry certificate
+func WithCAPEM(caPEM []byte) BaseClientOption {
+	return func(c *BaseClient) {
+		c.tlsOptions().caPEM = caPEM
+	}
+}
+
+// WithClientCertificateFiles option sets client certificate and key files
+// used for mutual TLS authentication
+func WithClientCertificateFiles(certFile string, keyFile string) BaseClientOption {
+	return func(c *BaseClient) {
+		c.tlsOptions().certFile = certFile
+		c.tlsOptions().keyFile = keyFile
+	}
+}
+
+// WithClientCertificatePEM option sets PEM encoded client certificate and
+// key used for mutual TLS authentication
+func WithClientCertificatePEM(certPEM []byte, keyPEM []byte) BaseClientOption {
+	return func(c *BaseClient) {
+		c.tlsOptions().certPEM = certPEM
+		c.tlsOptions().keyPEM = keyPEM
+	}
+}
+
+// WithCertificateReload option enables reloading of CA and client
+// certificate files, which are checked for changes at most once per interval
+func WithCertificateReload(interval time.Duration) BaseClientOption {
+	return func(c *BaseClient) {
+		c.tlsOptions().reloadInterval = interval
+	}
+}
+
+func (c *BaseClient) tlsOptions() *tlsOptions {
+	if c.tls == nil {
+		c.tls = &tlsOptions{}
+	}
+
+	return c.tls
+}
+
+// configureTLS configures TLS on copy of http client and its transport, so
+// TLS options compose with http client set by WithHTTPClient. Custom
+// transports, that are not *http.Transport, are left as they are, as they
+// configure TLS themselves.
+func configureTLS(httpClient *http.Client, opts *tlsOptions) (*http.Client, error) {
+	var transport *http.Transport
+
+	switch tr := httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = tr.Clone()
+	default:
+		return httpClient, nil
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	if err := opts.apply(transport.TLSClientConfig); err != nil {
+		return nil, err
+	}
+
+	client := *httpClient
+	client.Transport = transport
+
+	return &client, nil
+}
+
+func (o *tlsOptions) apply(config *tls.Config) error {
+	config.InsecureSkipVerify = o.insecure
+
+	if len(o.caPEM) > 0 {
+		pool, err := certPoolFromPEM(o.caPEM)
+		if err != nil {
+			return err
+		}
+
+		config.RootCAs = pool
+	}
+
+	if len(o.certPEM) > 0 || len(o.keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(o.certPEM, o.keyPEM)
+		if err != nil {
+			return fmt.Errorf("error loading client certificate: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.caFile == "" && o.certFile == "" {
+		return nil
+	}
+
+	reloader := &certReloader{
+		caFile:   o.caFile,
+		certFile: o.certFile,
+		keyFile:  o.keyFile,
+		interval: o.reloadInterval,
+		now:      time.Now,
+	}
+
+	if err := reloader.load(); err != nil {
+		return err
+	}
+
+	if o.certFile != "" {
+		config.Certificates = nil
+		config.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	if o.caFile != "" {
+		if o.reloadInterval == 0 || o.insecure {
+			config.RootCAs = reloader.roots
+			return nil
+		}
+
+		// verify server certificates using reloaded CA certificates, as
+		// root CAs cannot be changed on existing config
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = reloader.VerifyConnection
+	}
+
+	return nil
+}
+
+func certPoolFromPEM(caPEM []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("error loading CA certificates: no valid certificates found")
+	}
+
+	return pool, nil
+}
+
+// certReloader loads CA and client certificates from files and reloads
+// them when files change
+type certReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.Mutex
+	roots     *x509.CertPool
+	cert      *tls.Certificate
+	checkedAt time.Time
+	modTimes  map[string]time.Time
+
+	now func() time.Time
+}
+
+func (r *certReloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reload()
+}
+
+// reload loads certificates, if any of certificate files changed
+func (r *certReloader) reload() error {
+	r.checkedAt = r.now()
+
+	modTimes := map[string]time.Time{}
+	changed := r.modTimes == nil
+	for _, file := range []string{r.caFile, r.certFile, r.keyFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("error reading certificate file: %w", err)
+		}
+
+		modTimes[file] = info.ModTime()
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if r.caFile != "" {
+		caPEM, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("error reading CA file: %w", err)
+		}
+
+		pool, err := certPoolFromPEM(caPEM)
+		if err != nil {
+			return err
+		}
+
+		r.roots = pool
+	}
+
+	if r.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("error loading client certificate: %w", err)
+		}
+
+		r.cert = &cert
+	}
+
+	r.modTimes = modTimes
+
+	return nil
+}
+
+// maybeReload reloads certificates if reload interval has passed, keeping
+// previous certificates if reload fails, as files might be in the middle
+// of being rotated
+func (r *certReloader) maybeReload() {
+	if r.interval > 0 && r.now().Sub(r.checkedAt) >= r.interval {
+		r.reload()
+	}
+}
+
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.maybeReload()
+
+	return r.cert, nil
+}
+
+func (r *certReloader) VerifyConnection(cs tls.ConnectionState) error {
+	r.mu.Lock()
+	r.maybeReload()
+	roots := r.roots
+	r.mu.Unlock()
+
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("server did not provide certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
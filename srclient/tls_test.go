@@ -0,0 +1,312 @@
+package srclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	require.NoError(t, err)
+	return cert
+}
+
+var testCertSerial int64
+
+func newTestCert(t *testing.T, cn string, parent *testCert, server bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	testCertSerial++
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(testCertSerial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// tlsTestServer is TLS server requiring client certificates, which responds
+// to subjects request with common name of client certificate
+type tlsTestServer struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	cert tls.Certificate
+}
+
+func newTLSTestServer(t *testing.T, cert *testCert, clientCA *testCert) *tlsTestServer {
+	s := &tlsTestServer{cert: cert.tlsCertificate(t)}
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]string{r.TLS.PeerCertificates[0].Subject.CommonName})
+	}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+
+	s.TLS = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			return &tls.Config{
+				Certificates: []tls.Certificate{s.cert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    clientCAs,
+			}, nil
+		},
+	}
+	s.StartTLS()
+
+	return s
+}
+
+func (s *tlsTestServer) setCertificate(cert tls.Certificate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert = cert
+}
+
+func writeTestFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestTLSCAAndClientCertificatePEM(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, false)
+	server := newTLSTestServer(t, newTestCert(t, "server", ca, true), ca)
+	defer server.Close()
+
+	ctx := context.Background()
+	client1 := newTestCert(t, "client1", ca, false)
+
+	client := NewBaseClient(
+		WithURL(server.URL),
+		WithRetries(0),
+		WithCAPEM(ca.certPEM),
+		WithClientCertificatePEM(client1.certPEM, client1.keyPEM),
+	)
+
+	subjects, err := client.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"client1"}, subjects)
+
+	// server certificate is not trusted without CA
+	client = NewBaseClient(
+		WithURL(server.URL),
+		WithRetries(0),
+		WithClientCertificatePEM(client1.certPEM, client1.keyPEM),
+	)
+
+	_, err = client.GetSubjects(ctx)
+	require.Error(t, err)
+
+	// server requires client certificate
+	client = NewBaseClient(WithURL(server.URL), WithRetries(0), WithCAPEM(ca.certPEM))
+
+	_, err = client.GetSubjects(ctx)
+	require.Error(t, err)
+
+	require.Panics(t, func() { NewBaseClient(WithCAPEM([]byte("invalid"))) })
+	require.Panics(t, func() { NewBaseClient(WithCAFile(filepath.Join(t.TempDir(), "missing.pem"))) })
+
+	_, err = NewBaseClientWithError(WithCAFile(filepath.Join(t.TempDir(), "missing.pem")))
+	require.Error(t, err)
+
+	_, err = NewClientWithError(WithCaching(), WithCAPEM([]byte("invalid")))
+	require.Error(t, err)
+}
+
+func TestTLSComposesWithHTTPClient(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, false)
+
+	transport := &http.Transport{MaxIdleConns: 7}
+	httpClient := &http.Client{Transport: transport, Timeout: time.Minute}
+
+	// tls options are applied regardless of option order
+	client := NewBaseClient(
+		WithInsecure(),
+		WithHTTPClient(httpClient),
+		WithCAPEM(ca.certPEM),
+	)
+
+	tr, ok := client.httpClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, 7, tr.MaxIdleConns)
+	require.Equal(t, time.Minute, client.httpClient.Timeout)
+	require.True(t, tr.TLSClientConfig.InsecureSkipVerify)
+	require.NotNil(t, tr.TLSClientConfig.RootCAs)
+
+	// provided http client and transport are not modified
+	require.True(t, httpClient.Transport == transport)
+	require.True(t, transport.TLSClientConfig == nil || transport.TLSClientConfig.RootCAs == nil)
+
+	// custom round trippers are used as they are
+	custom := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+
+	client = NewBaseClient(WithHTTPClient(custom), WithInsecure())
+	require.True(t, client.httpClient == custom)
+
+	_, ok = client.httpClient.Transport.(roundTripperFunc)
+	require.True(t, ok)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	ca1 := newTestCert(t, "ca1", nil, false)
+	server := newTLSTestServer(t, newTestCert(t, "server", ca1, true), ca1)
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	modTime := time.Now().Add(-time.Minute)
+	client1 := newTestCert(t, "client1", ca1, false)
+	writeTestFile(t, caFile, ca1.certPEM, modTime)
+	writeTestFile(t, certFile, client1.certPEM, modTime)
+	writeTestFile(t, keyFile, client1.keyPEM, modTime)
+
+	ctx := context.Background()
+	client := NewBaseClient(
+		WithURL(server.URL),
+		WithRetries(0),
+		WithHTTPClient(&http.Client{Transport: &http.Transport{DisableKeepAlives: true}}),
+		WithCAFile(caFile),
+		WithClientCertificateFiles(certFile, keyFile),
+		WithCertificateReload(10*time.Millisecond),
+	)
+
+	subjects, err := client.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"client1"}, subjects)
+
+	// rotate client certificate, server certificate and CA
+	ca2 := newTestCert(t, "ca2", nil, false)
+	client2 := newTestCert(t, "client2", ca1, false)
+	server.setCertificate(newTestCert(t, "server", ca2, true).tlsCertificate(t))
+
+	modTime = time.Now()
+	writeTestFile(t, caFile, ca2.certPEM, modTime)
+	writeTestFile(t, certFile, client2.certPEM, modTime)
+	writeTestFile(t, keyFile, client2.keyPEM, modTime)
+
+	time.Sleep(20 * time.Millisecond)
+
+	subjects, err = client.GetSubjects(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"client2"}, subjects)
+}
+
+func TestCertReloaderInterval(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, false)
+	client1 := newTestCert(t, "client1", ca, false)
+	client2 := newTestCert(t, "client2", ca, false)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	modTime := time.Now().Add(-time.Minute)
+	writeTestFile(t, certFile, client1.certPEM, modTime)
+	writeTestFile(t, keyFile, client1.keyPEM, modTime)
+
+	now := time.Now()
+	reloader := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: time.Minute,
+		now:      func() time.Time { return now },
+	}
+	require.NoError(t, reloader.load())
+
+	commonName := func() string {
+		cert, err := reloader.GetClientCertificate(nil)
+		require.NoError(t, err)
+
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+
+		return parsed.Subject.CommonName
+	}
+
+	require.Equal(t, "client1", commonName())
+
+	// partially rotated files keep previous certificate
+	writeTestFile(t, certFile, client2.certPEM, now)
+	now = now.Add(time.Minute)
+	require.Equal(t, "client1", commonName())
+
+	// files are not checked again before interval passes
+	writeTestFile(t, keyFile, client2.keyPEM, now)
+	now = now.Add(time.Second)
+	require.Equal(t, "client1", commonName())
+
+	now = now.Add(time.Minute)
+	require.Equal(t, "client2", commonName())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/url"
+	"sort"
 )
 
 type pathParam string
@@ -31,6 +32,36 @@ func (u urlPath) Format(params ...interface{}) urlPath {
 	return urlPath(fmt.Sprintf(string(u), pathParams...))
 }
 
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// insertSorted returns copy of sorted values with value inserted
+func insertSorted(values []int, value int) []int {
+	i := sort.SearchInts(values, value)
+
+	result := make([]int, 0, len(values)+1)
+	result = append(result, values[:i]...)
+	result = append(result, value)
+	return append(result, values[i:]...)
+}
+
 func enableOpt(opts []bool) bool {
 	if len(opts) > 0 {
 		return opts[0]
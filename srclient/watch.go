@@ -0,0 +1,381 @@
+package srclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const defaultPollInterval = 30 * time.Second
+
+// WatchEventType is type of change of watched subject
+type WatchEventType int
+
+const (
+	// VersionCreatedEvent is sent when new subject version is registered
+	VersionCreatedEvent WatchEventType = iota
+
+	// VersionDeletedEvent is sent when subject version is soft deleted
+	VersionDeletedEvent
+
+	// VersionPermanentlyDeletedEvent is sent when soft deleted subject version
+	// is permanently deleted
+	VersionPermanentlyDeletedEvent
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case VersionCreatedEvent:
+		return "version created"
+	case VersionDeletedEvent:
+		return "version deleted"
+	case VersionPermanentlyDeletedEvent:
+		return "version permanently deleted"
+	}
+
+	return "unknown"
+}
+
+// WatchEvent is change of watched subject
+type WatchEvent struct {
+	Type    WatchEventType
+	Subject string
+	Version int
+
+	// ID is id of schema registered under version, or zero if it is not known
+	ID int
+
+	// Schema is registered schema, only set for created versions
+	Schema *Schema
+}
+
+// watchedSubject is state of watched subject
+type watchedSubject struct {
+	name string
+
+	// versions are active versions mapped to schema ids, where id is zero if
+	// it is not known
+	versions map[int]int
+
+	// deleted are soft deleted versions mapped to schema ids, which are
+	// listed with all subject versions until they are permanently deleted
+	deleted map[int]int
+}
+
+type WatcherOption func(*Watcher)
+
+// WithPollInterval sets interval of polling subjects for changes
+func WithPollInterval(interval time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.interval = interval
+	}
+}
+
+// WithWatchErrorHandler sets handler called with errors polling subjects,
+// which are retried on next poll
+func WithWatchErrorHandler(handler func(err error)) WatcherOption {
+	return func(w *Watcher) {
+		w.onError = handler
+	}
+}
+
+// Watcher polls subjects for new and deleted versions
+//
+// Subject versions are polled on each interval, and schemas are only
+// requested for new versions, with latest version requested as latest
+// schema. Deleted versions are detected as soon as they are soft deleted,
+// and permanent deletes by polling all subject versions including soft
+// deleted versions, while subject has soft deleted versions.
+type Watcher struct {
+	client   Client
+	interval time.Duration
+	onError  func(err error)
+
+	// cache is caching client invalidated on changes, if watched client is
+	// caching client
+	cache *CachingClient
+}
+
+// NewWatcher creates watcher of subjects of schema registry client. If client
+// is CachingClient, subjects are polled using its upstream client and its
+// entries are invalidated when subjects change.
+func NewWatcher(client Client, opts ...WatcherOption) *Watcher {
+	if client == nil {
+		panic("client must be set")
+	}
+
+	w := &Watcher{client: client, interval: defaultPollInterval}
+
+	if cachingClient, ok := client.(*CachingClient); ok {
+		w.client = cachingClient.client
+		w.cache = cachingClient
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.interval <= 0 {
+		panic(fmt.Errorf("invalid poll interval: %s", w.interval))
+	}
+
+	return w
+}
+
+// Watch gets current versions of subjects, then polls subjects until context
+// is done and sends their changes on returned channel, which is closed when
+// watch stops
+func (w *Watcher) Watch(ctx context.Context, subjects ...string) (<-chan WatchEvent, error) {
+	watched := []*watchedSubject{}
+	for _, subject := range subjects {
+		if containsWatchedSubject(watched, subject) {
+			continue
+		}
+
+		s := &watchedSubject{
+			name:     subject,
+			versions: map[int]int{},
+			deleted:  map[int]int{},
+		}
+
+		if err := w.init(ctx, s); err != nil {
+			return nil, fmt.Errorf("error watching subject %s: %w", subject, err)
+		}
+
+		watched = append(watched, s)
+	}
+
+	events := make(chan WatchEvent)
+	go w.run(ctx, watched, events)
+
+	return events, nil
+}
+
+func containsWatchedSubject(subjects []*watchedSubject, name string) bool {
+	for _, s := range subjects {
+		if s.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Watcher) run(ctx context.Context, subjects []*watchedSubject, events chan<- WatchEvent) {
+	defer close(events)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, s := range subjects {
+			changes, err := w.poll(ctx, s)
+
+			for _, event := range changes {
+				w.invalidate(event)
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if err != nil && ctx.Err() == nil && w.onError != nil {
+				w.onError(fmt.Errorf("error watching subject %s: %w", s.name, err))
+			}
+		}
+	}
+}
+
+// getVersions gets versions of subject, where subject without versions is
+// not found
+func (w *Watcher) getVersions(ctx context.Context, subject string) ([]int, error) {
+	versions, err := w.client.GetSubjectVersions(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) {
+		return nil, nil
+	}
+
+	return versions, err
+}
+
+// getAllVersions gets versions of subject including soft deleted versions
+func (w *Watcher) getAllVersions(ctx context.Context, subject string) ([]int, error) {
+	versions, err := w.client.GetAllSubjectVersions(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) {
+		return nil, nil
+	}
+
+	return versions, err
+}
+
+// poll polls subject and returns its changes since last poll
+func (w *Watcher) poll(ctx context.Context, s *watchedSubject) ([]WatchEvent, error) {
+	versions, err := w.getVersions(ctx, s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []WatchEvent{}
+	active := map[int]bool{}
+
+	for _, version := range versions {
+		active[version] = true
+
+		if _, ok := s.versions[version]; ok {
+			continue
+		}
+
+		schema, err := w.getSchema(ctx, s.name, version, version == versions[len(versions)-1])
+		if errors.Is(err, ErrVersionNotFound) {
+			// version was deleted after versions were polled
+			continue
+		}
+
+		if err != nil {
+			return events, err
+		}
+
+		s.versions[version] = schema.ID
+		events = append(events, WatchEvent{
+			Type:    VersionCreatedEvent,
+			Subject: s.name,
+			Version: version,
+			ID:      schema.ID,
+			Schema:  schema,
+		})
+	}
+
+	removed := []int{}
+	for version := range s.versions {
+		if !active[version] {
+			removed = append(removed, version)
+		}
+	}
+	sort.Ints(removed)
+
+	for _, version := range removed {
+		id := s.versions[version]
+		delete(s.versions, version)
+
+		events = append(events, WatchEvent{Type: VersionDeletedEvent, Subject: s.name, Version: version, ID: id})
+		s.deleted[version] = id
+	}
+
+	if len(s.deleted) == 0 {
+		return events, nil
+	}
+
+	// soft deleted versions, that are not listed with all versions, are
+	// permanently deleted
+	all, err := w.getAllVersions(ctx, s.name)
+	if err != nil {
+		return events, err
+	}
+
+	existing := map[int]bool{}
+	for _, version := range all {
+		existing[version] = true
+	}
+
+	deleted := []int{}
+	for version := range s.deleted {
+		if !existing[version] {
+			deleted = append(deleted, version)
+		}
+	}
+	sort.Ints(deleted)
+
+	for _, version := range deleted {
+		id := s.deleted[version]
+		delete(s.deleted, version)
+
+		events = append(events, WatchEvent{Type: VersionPermanentlyDeletedEvent, Subject: s.name, Version: version, ID: id})
+	}
+
+	return events, nil
+}
+
+// init initializes state of subject with its current and soft deleted
+// versions
+func (w *Watcher) init(ctx context.Context, s *watchedSubject) error {
+	// all versions are listed first, so versions created in between are not
+	// taken as soft deleted
+	all, err := w.getAllVersions(ctx, s.name)
+	if err != nil {
+		return err
+	}
+
+	versions, err := w.getVersions(ctx, s.name)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		s.versions[version] = 0
+	}
+
+	for _, version := range all {
+		if _, ok := s.versions[version]; !ok {
+			s.deleted[version] = 0
+		}
+	}
+
+	if len(versions) > 0 {
+		schema, err := w.client.GetLatestSchema(ctx, s.name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		if err == nil {
+			if _, ok := s.versions[schema.Version]; ok {
+				s.versions[schema.Version] = schema.ID
+			}
+		}
+	}
+
+	return nil
+}
+
+// getSchema gets schema of subject version, requesting latest subject schema
+// if version is latest polled version
+func (w *Watcher) getSchema(ctx context.Context, subject string, version int, latest bool) (*Schema, error) {
+	if latest {
+		schema, err := w.client.GetLatestSchema(ctx, subject)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+
+		// latest version can change after versions were polled
+		if err == nil && schema.Version == version {
+			return schema, nil
+		}
+	}
+
+	return w.client.GetSchemaByVersion(ctx, subject, version)
+}
+
+// invalidate invalidates entries of caching client changed by event
+func (w *Watcher) invalidate(event WatchEvent) {
+	if w.cache == nil {
+		return
+	}
+
+	switch event.Type {
+	case VersionCreatedEvent:
+		w.cache.negative.Invalidate(event.Subject, event.ID)
+		w.cache.cache.CacheCreatedSchema(event.Schema)
+	case VersionDeletedEvent:
+		w.cache.cache.InvalidateSchemaByVersion(event.Subject, event.Version, false)
+	case VersionPermanentlyDeletedEvent:
+		w.cache.cache.InvalidateSchemaByVersion(event.Subject, event.Version, true)
+	}
+}
@@ -0,0 +1,195 @@
+package srclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xtruder/go-kafka-protobuf/srclient/srtest"
+)
+
+func nextWatchEvent(t *testing.T, events <-chan WatchEvent) WatchEvent {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "watch stopped")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for watch event")
+	}
+
+	return WatchEvent{}
+}
+
+func TestWatcher(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	base := NewBaseClient(WithURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	create := func(subject string, schema string) *Schema {
+		created, err := base.CreateSchema(ctx, &Schema{Subject: subject, Schema: schema})
+		require.NoError(t, err)
+
+		return created
+	}
+
+	a := create("s1", "a")
+
+	errs := make(chan error, 10)
+	w := NewWatcher(base, WithPollInterval(10*time.Millisecond), WithWatchErrorHandler(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+
+	events, err := w.Watch(ctx, "s1", "s2", "s1")
+	require.NoError(t, err)
+
+	// created versions are sent with registered schema
+	b := create("s1", "b")
+	require.Equal(t, WatchEvent{Type: VersionCreatedEvent, Subject: "s1", Version: 2, ID: b.ID, Schema: b}, nextWatchEvent(t, events))
+
+	c := create("s2", "c")
+	require.Equal(t, WatchEvent{Type: VersionCreatedEvent, Subject: "s2", Version: 1, ID: c.ID, Schema: c}, nextWatchEvent(t, events))
+
+	// id of latest version is known when watch starts
+	_, err = base.DeleteSchemaByVersion(ctx, "s1", 1, false)
+	require.NoError(t, err)
+	require.Equal(t, WatchEvent{Type: VersionDeletedEvent, Subject: "s1", Version: 1, ID: a.ID}, nextWatchEvent(t, events))
+
+	// soft deleted versions are checked until they are permanently deleted
+	req, err := http.NewRequest("DELETE", server.URL+"/subjects/s1/versions/1?permanent=true", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, WatchEvent{Type: VersionPermanentlyDeletedEvent, Subject: "s1", Version: 1, ID: a.ID}, nextWatchEvent(t, events))
+
+	// permanently deleted subject is soft deleted first
+	_, err = base.DeleteSubject(ctx, "s2", true)
+	require.NoError(t, err)
+	require.Equal(t, WatchEvent{Type: VersionDeletedEvent, Subject: "s2", Version: 1, ID: c.ID}, nextWatchEvent(t, events))
+	require.Equal(t, WatchEvent{Type: VersionPermanentlyDeletedEvent, Subject: "s2", Version: 1, ID: c.ID}, nextWatchEvent(t, events))
+
+	// errors are reported and polling is retried
+	server.Close()
+	require.Error(t, <-errs)
+
+	cancel()
+	for range events {
+	}
+
+	require.Panics(t, func() { NewWatcher(base, WithPollInterval(0)) })
+}
+
+func TestWatcherPermanentDeletes(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	base := NewBaseClient(WithURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	permanentlyDelete := func(subject string, version int) {
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/subjects/%s/versions/%d?permanent=true", server.URL, subject, version), nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	for _, schema := range []string{"a", "b", "c"} {
+		_, err := base.CreateSchema(ctx, &Schema{Subject: "s1", Schema: schema})
+		require.NoError(t, err)
+	}
+
+	// schema of first version is shared with other subject
+	_, err := base.CreateSchema(ctx, &Schema{Subject: "s2", Schema: "a"})
+	require.NoError(t, err)
+
+	_, err = base.DeleteSchemaByVersion(ctx, "s1", 3, false)
+	require.NoError(t, err)
+
+	events, err := NewWatcher(base, WithPollInterval(10*time.Millisecond)).Watch(ctx, "s1")
+	require.NoError(t, err)
+
+	// permanent deletes are detected for versions with unknown ids and
+	// schemas used by other subjects
+	_, err = base.DeleteSchemaByVersion(ctx, "s1", 1, false)
+	require.NoError(t, err)
+	require.Equal(t, WatchEvent{Type: VersionDeletedEvent, Subject: "s1", Version: 1}, nextWatchEvent(t, events))
+
+	permanentlyDelete("s1", 1)
+	require.Equal(t, WatchEvent{Type: VersionPermanentlyDeletedEvent, Subject: "s1", Version: 1}, nextWatchEvent(t, events))
+
+	// versions soft deleted before watch started are watched for permanent
+	// deletes
+	permanentlyDelete("s1", 3)
+	require.Equal(t, WatchEvent{Type: VersionPermanentlyDeletedEvent, Subject: "s1", Version: 3}, nextWatchEvent(t, events))
+}
+
+func TestWatcherError(t *testing.T) {
+	server := srtest.NewServer()
+	server.Close()
+
+	w := NewWatcher(NewBaseClient(WithURL(server.URL), WithRetries(0)))
+
+	_, err := w.Watch(context.Background(), "subject")
+	require.Error(t, err)
+}
+
+func TestWatcherCachingClient(t *testing.T) {
+	server := srtest.NewServer()
+	defer server.Close()
+
+	base := NewBaseClient(WithURL(server.URL))
+	cc := NewCachingClient(base)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, err := base.CreateSchema(ctx, &Schema{Subject: "subject", Schema: "a"})
+	require.NoError(t, err)
+
+	latest, err := cc.GetLatestSchema(ctx, "subject")
+	require.NoError(t, err)
+	require.Equal(t, a.ID, latest.ID)
+
+	events, err := NewWatcher(cc, WithPollInterval(10*time.Millisecond)).Watch(ctx, "subject")
+	require.NoError(t, err)
+
+	// schemas changed using other clients are updated in cache
+	b, err := base.CreateSchema(ctx, &Schema{Subject: "subject", Schema: "b"})
+	require.NoError(t, err)
+	require.Equal(t, VersionCreatedEvent, nextWatchEvent(t, events).Type)
+
+	latest, err = cc.GetLatestSchema(ctx, "subject")
+	require.NoError(t, err)
+	require.Equal(t, b.ID, latest.ID)
+
+	versions, err := cc.GetSubjectVersions(ctx, "subject")
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, versions)
+
+	_, err = base.DeleteSchemaByVersion(ctx, "subject", 2, false)
+	require.NoError(t, err)
+	require.Equal(t, VersionDeletedEvent, nextWatchEvent(t, events).Type)
+
+	latest, err = cc.GetLatestSchema(ctx, "subject")
+	require.NoError(t, err)
+	require.Equal(t, a.ID, latest.ID)
+
+	requireCached(t, cc, false, "version/subject/2")
+}
@@ -9,12 +9,20 @@ import (
 )
 
 func initSchemaRegistryFlags(cmd *cobra.Command) {
-	cmd.Flags().String("registry-url", "", "Schema registry URL")
+	cmd.Flags().String("registry-url", "", "Schema registry URL or comma separated list of URLs")
 	cmd.MarkFlagRequired("registry-url")
 
 	cmd.Flags().String("registry-credentials", "", "Schema registry credentials in format of 'user:pass'")
 
+	cmd.Flags().String("registry-token", "", "Schema registry bearer token")
+
 	cmd.Flags().Bool("registry-insecure", false, "Wheter insecure connections to schema registry are allowed")
+
+	cmd.Flags().String("registry-ca-file", "", "Schema registry CA certificates file")
+
+	cmd.Flags().String("registry-cert-file", "", "Schema registry client certificate file")
+
+	cmd.Flags().String("registry-key-file", "", "Schema registry client key file")
 }
 
 func initSchemaRegistryClient(cmd *cobra.Command) (srclient.Client, error) {
@@ -42,12 +50,50 @@ func initSchemaRegistryClient(cmd *cobra.Command) (srclient.Client, error) {
 		opts = append(opts, srclient.WithCredentials(credPair[0], credPair[1]))
 	}
 
+	token, err := cmd.Flags().GetString("registry-token")
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		opts = append(opts, srclient.WithAuth(srclient.BearerToken(token)))
+	}
+
 	insecure, err := cmd.Flags().GetBool("registry-insecure")
 	if err != nil {
 		return nil, err
 	}
 
-	opts = append(opts, srclient.WithInsecure(insecure))
+	if insecure {
+		opts = append(opts, srclient.WithInsecure())
+	}
+
+	caFile, err := cmd.Flags().GetString("registry-ca-file")
+	if err != nil {
+		return nil, err
+	}
+
+	if caFile != "" {
+		opts = append(opts, srclient.WithCAFile(caFile))
+	}
+
+	certFile, err := cmd.Flags().GetString("registry-cert-file")
+	if err != nil {
+		return nil, err
+	}
+
+	keyFile, err := cmd.Flags().GetString("registry-key-file")
+	if err != nil {
+		return nil, err
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("both schema registry client certificate and key files must be set")
+		}
+
+		opts = append(opts, srclient.WithClientCertificateFiles(certFile, keyFile))
+	}
 
-	return srclient.NewClient(opts...), nil
+	return srclient.NewClientWithError(opts...)
 }